@@ -27,13 +27,31 @@ func (a *Application) Start() {
 
 	r.Handle("/*", http.FileServer(http.Dir("web")))
 
+	r.Get("/api/status", a.handler.Status)
 	r.Get("/api/nextdate", a.handler.NextDate)
+	r.Get("/api/nextdates", a.handler.NextDates)
+	r.Get("/api/date/preview", a.handler.PreviewDate)
+	r.Get("/api/repeat/parse", a.handler.ParseRepeat)
+	r.Get("/api/repeat/rrule", a.handler.ConvertToRRule)
+	r.Get("/api/repeat/describe", a.handler.DescribeRepeat)
 	r.Get("/api/task", a.handler.GetTask)
 	r.Post("/api/task", a.handler.AddTask)
 	r.Put("/api/task", a.handler.PutTask)
 	r.Get("/api/tasks", a.handler.GetClosestTasks)
 	r.Post("/api/task/done", a.handler.DoTask)
+	r.Get("/api/task/history", a.handler.GetTaskHistory)
+	r.Get("/api/history", a.handler.GetRecentCompletions)
+	r.Get("/api/trash", a.handler.GetTrash)
+	r.Post("/api/task/restore", a.handler.RestoreTask)
 	r.Delete("/api/task", a.handler.DeleteTask)
+	r.Post("/api/task/exception", a.handler.AddTaskException)
+	r.Delete("/api/task/exception", a.handler.DeleteTaskException)
+
+	r.Get("/api/holidays", a.handler.GetHolidays)
+	r.Post("/api/holidays", a.handler.AddHoliday)
+	r.Put("/api/holidays", a.handler.PutHoliday)
+	r.Delete("/api/holidays", a.handler.DeleteHoliday)
+	r.Post("/api/holidays/import", a.handler.ImportHolidays)
 
 	r.Post("/api/signin", auth.SingIn)
 
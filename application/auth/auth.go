@@ -18,29 +18,35 @@ func NewAuth(config *config.Config) Auth {
 	return Auth{
 		config: config,
 		addressAuth: map[string]bool{
-			"/api/task":      true,
-			"/api/tasks":     true,
-			"/api/task/done": true,
+			"/api/task":            true,
+			"/api/tasks":           true,
+			"/api/task/done":       true,
+			"/api/task/history":    true,
+			"/api/history":         true,
+			"/api/trash":           true,
+			"/api/task/restore":    true,
+			"/api/task/exception":  true,
+			"/api/holidays":        true,
+			"/api/holidays/import": true,
 		},
 	}
 }
 
 func (a Auth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !a.addressAuth[r.RequestURI] {
+		// подменять текущий момент можно только после входа, а без пароля входа нет и заголовок не принимается
+		if r.Header.Get(model.DebugNowHeader) != "" && (len(a.config.Pass) == 0 || !isJWTValid(a.config.Pass, tokenFromCookie(r))) {
+			http.Error(w, "Authentification required", http.StatusUnauthorized)
+			return
+		}
+
+		if !a.addressAuth[r.URL.Path] {
 			next.ServeHTTP(w, r)
 			return
 		}
 		// смотрим наличие пароля
 		if len(a.config.Pass) > 0 {
-			var jwt string // JWT-токен из куки
-			// получаем куку
-			cookie, err := r.Cookie("token")
-			if err == nil {
-				jwt = cookie.Value
-			}
-
-			if !isJWTValid(a.config.Pass, jwt) {
+			if !isJWTValid(a.config.Pass, tokenFromCookie(r)) {
 				http.Error(w, "Authentification required", http.StatusUnauthorized)
 				return
 			}
@@ -95,6 +101,16 @@ func prepareResponse(w http.ResponseWriter, response any, httpStatus int) {
 	w.WriteHeader(httpStatus)
 }
 
+// tokenFromCookie получает JWT-токен из куки
+func tokenFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie("token")
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
 func isJWTValid(pass string, token string) bool {
 	jwtToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
 		return []byte(pass), nil
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_final_project/service/model"
+	"go_final_project/service/validator"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (h *SchedulerHandler) GetHolidays(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareGetHolidaysRequest(r)
+	if err != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	holidays, serviceErr := h.service.GetHolidays(request)
+	if serviceErr != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("не удалось получить праздники: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.GetHolidaysResponse{Holidays: holidays}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) AddHoliday(w http.ResponseWriter, r *http.Request) {
+	h.saveHoliday(w, r, h.service.AddHoliday)
+}
+
+func (h *SchedulerHandler) PutHoliday(w http.ResponseWriter, r *http.Request) {
+	h.saveHoliday(w, r, h.service.PutHoliday)
+}
+
+func (h *SchedulerHandler) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	request := model.DeleteHolidayRequest{Date: r.URL.Query().Get("date")}
+
+	if errValid := validator.ValidateDeleteHolidayRequest(request); errValid != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if serviceErr := h.service.DeleteHoliday(request); serviceErr != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("ошибка при удалении праздника: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.HolidayResponse{}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareImportHolidaysRequest(r)
+	if err != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateImportHolidaysRequest(request); errValid != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	response, serviceErr := h.service.ImportHolidays(request)
+	if serviceErr != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("ошибка при загрузке праздников: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) saveHoliday(w http.ResponseWriter, r *http.Request, save func(request model.HolidayRequest) error) {
+	var request model.HolidayRequest
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: ошибка десериализации JSON: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateHolidayRequest(request); errValid != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if serviceErr := save(request); serviceErr != nil {
+		errResp := &model.HolidayResponseWithError{
+			Error: fmt.Sprintf("ошибка при сохранении праздника: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.HolidayResponse{}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) prepareGetHolidaysRequest(r *http.Request) (model.GetHolidaysRequest, error) {
+	yearStr := r.URL.Query().Get("year")
+	if yearStr == "" {
+		return model.GetHolidaysRequest{}, nil
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return model.GetHolidaysRequest{}, fmt.Errorf("год должен быть числом: %s", err.Error())
+	}
+
+	return model.GetHolidaysRequest{Year: year}, nil
+}
+
+// prepareImportHolidaysRequest читает файл праздников из тела запроса, формат берётся из параметра format или Content-Type
+func (h *SchedulerHandler) prepareImportHolidaysRequest(r *http.Request) (model.ImportHolidaysRequest, error) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return model.ImportHolidaysRequest{}, fmt.Errorf("не удалось прочитать файл праздников: %s", err.Error())
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		switch contentType := r.Header.Get("Content-Type"); {
+		case strings.HasPrefix(contentType, "text/csv"):
+			format = model.HolidaysFormatCSV
+		case strings.HasPrefix(contentType, "text/calendar"):
+			format = model.HolidaysFormatICS
+		}
+	}
+
+	return model.ImportHolidaysRequest{Format: format, Content: content}, nil
+}
@@ -7,7 +7,10 @@ import (
 	"go_final_project/service"
 	"go_final_project/service/model"
 	"go_final_project/service/validator"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -45,6 +48,46 @@ func (h *SchedulerHandler) NextDate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *SchedulerHandler) NextDates(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareNextDatesRequest(r)
+	if err != nil {
+		errResp := &model.NextDatesResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateNextDatesRequest(request); errValid != nil {
+		errResp := &model.NextDatesResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	nextDates, serviceErr := h.service.CalculateNextDates(request)
+	if serviceErr != nil {
+		errResp := &model.NextDatesResponseWithError{
+			Error: fmt.Sprintf("ошибка при вычислении дат: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	//Правила h и min повторяются несколько раз в день, поэтому их даты возвращаются вместе со временем
+	layout := model.CommonDateFormat
+	if service.IsIntradayRule(request.Repeat) {
+		layout = model.DateTimeFormat
+	}
+	response := model.NextDatesResponse{Dates: make([]string, 0, len(nextDates))}
+	for _, nextDate := range nextDates {
+		response.Dates = append(response.Dates, nextDate.Format(layout))
+	}
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
 func (h *SchedulerHandler) AddTask(w http.ResponseWriter, r *http.Request) {
 	addTaskRequest, err := h.prepareAddTaskRequest(r)
 	if err != nil {
@@ -143,6 +186,87 @@ func (h *SchedulerHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	h.doTask(w, r, true)
 }
 
+func (h *SchedulerHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	request := model.TrashRequest{Lang: h.prepareLang(r)}
+
+	if errValid := validator.ValidateTrashRequest(request); errValid != nil {
+		tasksRespErr := model.ClosestTasksResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, &tasksRespErr, http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.service.GetTrash(request)
+	if err != nil {
+		tasksRespErr := model.ClosestTasksResponseWithError{
+			Error: fmt.Sprintf("не удалось получить корзину: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, &tasksRespErr, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.ClosestTasksResponse{Tasks: tasks}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	request := model.RestoreTaskRequest{TaskId: r.URL.Query().Get("id")}
+
+	if errValid := validator.ValidateRestoreTaskRequest(request); errValid != nil {
+		errResp := &model.RestoreTaskResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if serviceErr := h.service.RestoreTask(request); serviceErr != nil {
+		errResp := &model.RestoreTaskResponseWithError{
+			Error: fmt.Sprintf("ошибка при восстановлении задания: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.RestoreTaskResponse{}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	request := model.TaskHistoryRequest{TaskId: r.URL.Query().Get("id")}
+
+	if errValid := validator.ValidateTaskHistoryRequest(request); errValid != nil {
+		errResp := &model.TaskHistoryResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	history, serviceErr := h.service.GetTaskHistory(request)
+	if serviceErr != nil {
+		errResp := &model.TaskHistoryResponseWithError{
+			Error: fmt.Sprintf("не удалось получить историю выполнения задания: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.TaskHistoryResponse{History: history}, http.StatusOK)
+}
+
+func (h *SchedulerHandler) GetRecentCompletions(w http.ResponseWriter, r *http.Request) {
+	history, serviceErr := h.service.GetRecentCompletions()
+	if serviceErr != nil {
+		errResp := &model.TaskHistoryResponseWithError{
+			Error: fmt.Sprintf("не удалось получить последние выполненные задания: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.TaskHistoryResponse{History: history}, http.StatusOK)
+}
+
 func (h *SchedulerHandler) GetClosestTasks(w http.ResponseWriter, r *http.Request) {
 	closestTasksRequest, err := h.prepareGetClosestTasksRequest(r)
 	if err != nil {
@@ -153,6 +277,14 @@ func (h *SchedulerHandler) GetClosestTasks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if errValid := validator.ValidateClosestTasksRequest(closestTasksRequest); errValid != nil {
+		tasksRespErr := model.ClosestTasksResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, &tasksRespErr, http.StatusBadRequest)
+		return
+	}
+
 	tasks, err := h.service.GetClosestTasks(closestTasksRequest)
 	if err != nil {
 		tasksRespErr := model.ClosestTasksResponseWithError{
@@ -165,6 +297,162 @@ func (h *SchedulerHandler) GetClosestTasks(w http.ResponseWriter, r *http.Reques
 	h.prepareTaskResponse(w, &model.ClosestTasksResponse{Tasks: tasks}, http.StatusOK)
 }
 
+func (h *SchedulerHandler) PreviewDate(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareDatePreviewRequest(r)
+	if err != nil {
+		errResp := &model.DatePreviewResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateDatePreviewRequest(request); errValid != nil {
+		errResp := &model.DatePreviewResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	response, serviceErr := h.service.PreviewDate(request)
+	if serviceErr != nil {
+		errResp := &model.DatePreviewResponseWithError{
+			Error: serviceErr.Error(),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) ParseRepeat(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareParseRepeatRequest(r)
+	if err != nil {
+		errResp := &model.ParseRepeatResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateParseRepeatRequest(request); errValid != nil {
+		errResp := &model.ParseRepeatResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	response := h.service.ParseRepeat(request)
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) Status(w http.ResponseWriter, r *http.Request) {
+	response := h.service.Status()
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) ConvertToRRule(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareRRuleRequest(r)
+	if err != nil {
+		errResp := &model.RRuleResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateRRuleRequest(request); errValid != nil {
+		errResp := &model.RRuleResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	response, serviceErr := h.service.ConvertToRRule(request)
+	if serviceErr != nil {
+		errResp := &model.RRuleResponseWithError{
+			Error: serviceErr.Error(),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) DescribeRepeat(w http.ResponseWriter, r *http.Request) {
+	request, err := h.prepareDescribeRepeatRequest(r)
+	if err != nil {
+		errResp := &model.DescribeRepeatResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if errValid := validator.ValidateDescribeRepeatRequest(request); errValid != nil {
+		errResp := &model.DescribeRepeatResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	response := h.service.DescribeRepeat(request)
+
+	h.prepareTaskResponse(w, &response, http.StatusOK)
+}
+
+func (h *SchedulerHandler) AddTaskException(w http.ResponseWriter, r *http.Request) {
+	var request model.TaskExceptionRequest
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		errResp := &model.TaskExceptionResponseWithError{
+			Error: fmt.Sprintf("не удалось распарсить данные запроса: ошибка десериализации JSON: %s", err.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	h.saveTaskException(w, request, h.service.AddTaskException)
+}
+
+func (h *SchedulerHandler) DeleteTaskException(w http.ResponseWriter, r *http.Request) {
+	request := model.TaskExceptionRequest{
+		TaskId: r.URL.Query().Get("id"),
+		Date:   r.URL.Query().Get("date"),
+	}
+
+	h.saveTaskException(w, request, h.service.DeleteTaskException)
+}
+
+func (h *SchedulerHandler) saveTaskException(w http.ResponseWriter, request model.TaskExceptionRequest, save func(request model.TaskExceptionRequest) error) {
+	if errValid := validator.ValidateTaskExceptionRequest(request); errValid != nil {
+		errResp := &model.TaskExceptionResponseWithError{
+			Error: fmt.Sprintf("валидация запроса не пройдена: %s", errValid.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusBadRequest)
+		return
+	}
+
+	if serviceErr := save(request); serviceErr != nil {
+		errResp := &model.TaskExceptionResponseWithError{
+			Error: fmt.Sprintf("ошибка при изменении исключений задания: %s", serviceErr.Error()),
+		}
+		h.prepareTaskResponse(w, errResp, http.StatusInternalServerError)
+		return
+	}
+
+	h.prepareTaskResponse(w, &model.TaskExceptionResponse{}, http.StatusOK)
+}
+
 func (h *SchedulerHandler) doTask(w http.ResponseWriter, r *http.Request, onlyDelete bool) {
 	request, err := h.prepareDoTaskRequest(r)
 	if err != nil {
@@ -183,16 +471,16 @@ func (h *SchedulerHandler) doTask(w http.ResponseWriter, r *http.Request, onlyDe
 		return
 	}
 
-	_, serviceErr := h.service.DoTask(request, onlyDelete)
+	response, serviceErr := h.service.DoTask(request, onlyDelete)
 	if serviceErr != nil {
-		response := model.DoTaskResponseWithError{
+		errResp := model.DoTaskResponseWithError{
 			Error: fmt.Sprintf("ошибка при выполнении задания: %s", serviceErr.Error()),
 		}
-		h.prepareTaskResponse(w, &response, http.StatusInternalServerError)
+		h.prepareTaskResponse(w, &errResp, http.StatusInternalServerError)
 		return
 	}
 
-	h.prepareTaskResponse(w, &model.DoTaskResponse{}, http.StatusOK)
+	h.prepareTaskResponse(w, &response, http.StatusOK)
 }
 
 func (h *SchedulerHandler) prepareNextDateRequest(r *http.Request) (model.NextDateRequest, error) {
@@ -219,14 +507,120 @@ func (h *SchedulerHandler) prepareNextDateRequest(r *http.Request) (model.NextDa
 	}
 
 	nextDateRequest := model.NextDateRequest{
-		Now:    dateNow,
-		Date:   date,
-		Repeat: repeatRule,
+		Now:        dateNow,
+		Date:       date,
+		Repeat:     repeatRule,
+		Exceptions: h.prepareExceptions(r),
 	}
 
 	return nextDateRequest, nil
 }
 
+func (h *SchedulerHandler) prepareNextDatesRequest(r *http.Request) (model.NextDatesRequest, error) {
+	query := r.URL.Query()
+
+	timezone, err := h.prepareTimezone(r)
+	if err != nil {
+		return model.NextDatesRequest{}, err
+	}
+
+	dateNow, err := h.prepareDebugNow(r)
+	if err != nil {
+		return model.NextDatesRequest{}, err
+	}
+	if dateNow.IsZero() {
+		dateNow = h.service.Now(timezone)
+	}
+	if nowStr := query.Get("now"); nowStr != "" {
+		dateNow, err = service.DateParse(nowStr)
+		if err != nil {
+			return model.NextDatesRequest{}, err
+		}
+	}
+
+	date, err := service.DateParse(query.Get("date"))
+	if err != nil {
+		return model.NextDatesRequest{}, err
+	}
+
+	repeatStr := query.Get("repeat")
+	if repeatStr == "" {
+		return model.NextDatesRequest{}, errors.New("не указано правило повторения")
+	}
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString(repeatStr)
+	if err != nil {
+		return model.NextDatesRequest{}, fmt.Errorf("ошибка парсига правил повторения при вычислении nextDates: %s", err.Error())
+	}
+
+	request := model.NextDatesRequest{
+		NextDateRequest: model.NextDateRequest{
+			Now:        dateNow,
+			Date:       date,
+			Repeat:     repeatRule,
+			Exceptions: h.prepareExceptions(r),
+		},
+	}
+
+	if countStr := query.Get("count"); countStr != "" {
+		request.Count, err = strconv.Atoi(countStr)
+		if err != nil {
+			return model.NextDatesRequest{}, fmt.Errorf("количество дат должно быть числом: %s", err.Error())
+		}
+	}
+
+	if untilStr := query.Get("until"); untilStr != "" {
+		request.Until, err = service.DateParse(untilStr)
+		if err != nil {
+			return model.NextDatesRequest{}, err
+		}
+	}
+
+	return request, nil
+}
+
+// prepareDate переводит дату задачи, указанную фразой или в формате ISO 8601, в формат CommonDateFormat
+func (h *SchedulerHandler) prepareDate(date string, timezone *time.Location, debugNow time.Time) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+
+	response, err := h.service.PreviewDate(model.DatePreviewRequest{Phrase: date, Timezone: timezone, DebugNow: debugNow})
+	if err != nil {
+		return "", err
+	}
+
+	return response.Date, nil
+}
+
+func (h *SchedulerHandler) prepareDatePreviewRequest(r *http.Request) (model.DatePreviewRequest, error) {
+	timezone, err := h.prepareTimezone(r)
+	if err != nil {
+		return model.DatePreviewRequest{}, err
+	}
+
+	debugNow, err := h.prepareDebugNow(r)
+	if err != nil {
+		return model.DatePreviewRequest{}, err
+	}
+
+	return model.DatePreviewRequest{
+		Phrase:   r.URL.Query().Get("date"),
+		Timezone: timezone,
+		DebugNow: debugNow,
+	}, nil
+}
+
+// prepareExceptions получает исключённые даты из параметра exceptions, перечисленные через запятую
+func (h *SchedulerHandler) prepareExceptions(r *http.Request) []string {
+	exceptionsStr := r.URL.Query().Get("exceptions")
+	if exceptionsStr == "" {
+		return nil
+	}
+
+	return strings.Split(exceptionsStr, ",")
+}
+
 func (h *SchedulerHandler) prepareAddTaskRequest(r *http.Request) (model.AddTaskRequest, error) {
 	var addTaskRequest model.AddTaskRequest
 
@@ -243,16 +637,44 @@ func (h *SchedulerHandler) prepareAddTaskRequest(r *http.Request) (model.AddTask
 		addTaskRequest.Repeat = repeatRule
 	}
 
+	timezone, err := h.prepareTimezone(r)
+	if err != nil {
+		return model.AddTaskRequest{}, err
+	}
+	addTaskRequest.Timezone = timezone
+
+	addTaskRequest.DebugNow, err = h.prepareDebugNow(r)
+	if err != nil {
+		return model.AddTaskRequest{}, err
+	}
+
+	addTaskRequest.Date, err = h.prepareDate(addTaskRequest.Date, timezone, addTaskRequest.DebugNow)
+	if err != nil {
+		return model.AddTaskRequest{}, err
+	}
+
 	return addTaskRequest, nil
 }
 
 func (h *SchedulerHandler) preparePutTaskRequest(r *http.Request) (model.PutTaskRequest, error) {
 	var putTaskRequest model.PutTaskRequest
 
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&putTaskRequest); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return model.PutTaskRequest{}, fmt.Errorf("ошибка чтения тела запроса: %s", err.Error())
+	}
+	if err := json.Unmarshal(body, &putTaskRequest); err != nil {
+		return model.PutTaskRequest{}, fmt.Errorf("ошибка десериализации JSON: %s", err.Error())
+	}
+
+	//Веб-интерфейс присылает не все поля задачи, поэтому запоминаем, какие из них переданы
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
 		return model.PutTaskRequest{}, fmt.Errorf("ошибка десериализации JSON: %s", err.Error())
 	}
+	_, putTaskRequest.HasTime = fields["time"]
+	_, putTaskRequest.HasRepeatUntil = fields["repeat_until"]
+	_, putTaskRequest.HasRepeatCount = fields["repeat_count"]
 
 	if putTaskRequest.Repeat != "" {
 		repeatRule, err := service.PrepareRepeatRuleFromRawString(putTaskRequest.Repeat)
@@ -263,21 +685,141 @@ func (h *SchedulerHandler) preparePutTaskRequest(r *http.Request) (model.PutTask
 		putTaskRequest.RepeatRule = repeatRule
 	}
 
+	timezone, err := h.prepareTimezone(r)
+	if err != nil {
+		return model.PutTaskRequest{}, err
+	}
+	putTaskRequest.Timezone = timezone
+
+	putTaskRequest.DebugNow, err = h.prepareDebugNow(r)
+	if err != nil {
+		return model.PutTaskRequest{}, err
+	}
+
+	putTaskRequest.Date, err = h.prepareDate(putTaskRequest.Date, timezone, putTaskRequest.DebugNow)
+	if err != nil {
+		return model.PutTaskRequest{}, err
+	}
+
 	return putTaskRequest, nil
 }
 
+func (h *SchedulerHandler) prepareParseRepeatRequest(r *http.Request) (model.ParseRepeatRequest, error) {
+	repeatStr := r.URL.Query().Get("repeat")
+	if repeatStr == "" {
+		return model.ParseRepeatRequest{}, nil
+	}
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString(repeatStr)
+	if err != nil {
+		return model.ParseRepeatRequest{}, fmt.Errorf("ошибка парсига правил повторения: %s", err.Error())
+	}
+
+	return model.ParseRepeatRequest{RepeatRaw: repeatStr, Repeat: repeatRule}, nil
+}
+
+func (h *SchedulerHandler) prepareRRuleRequest(r *http.Request) (model.RRuleRequest, error) {
+	repeatStr := r.URL.Query().Get("repeat")
+	if repeatStr == "" {
+		return model.RRuleRequest{}, nil
+	}
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString(repeatStr)
+	if err != nil {
+		return model.RRuleRequest{}, fmt.Errorf("ошибка парсига правил повторения при переводе в RRULE: %s", err.Error())
+	}
+
+	return model.RRuleRequest{RepeatRaw: repeatStr, Repeat: repeatRule}, nil
+}
+
+func (h *SchedulerHandler) prepareDescribeRepeatRequest(r *http.Request) (model.DescribeRepeatRequest, error) {
+	request := model.DescribeRepeatRequest{
+		RepeatRaw: r.URL.Query().Get("repeat"),
+		Lang:      h.prepareLang(r),
+	}
+	if request.RepeatRaw == "" {
+		return request, nil
+	}
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString(request.RepeatRaw)
+	if err != nil {
+		return model.DescribeRepeatRequest{}, fmt.Errorf("ошибка парсига правил повторения при описании правила: %s", err.Error())
+	}
+	request.Repeat = repeatRule
+
+	return request, nil
+}
+
 func (h *SchedulerHandler) prepareGetTaskRequest(r *http.Request) (model.GetTaskRequest, error) {
 	return model.GetTaskRequest{
 		TaskId: r.URL.Query().Get("id"),
+		Lang:   h.prepareLang(r),
 	}, nil
 }
 
+// prepareLang определяет язык ответа по параметру lang, а если его нет - по заголовку Accept-Language
+func (h *SchedulerHandler) prepareLang(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return lang
+	}
+
+	if strings.HasPrefix(r.Header.Get("Accept-Language"), model.LangEn) {
+		return model.LangEn
+	}
+
+	return model.LangRu
+}
+
 func (h *SchedulerHandler) prepareDoTaskRequest(r *http.Request) (model.DoTaskRequest, error) {
+	timezone, err := h.prepareTimezone(r)
+	if err != nil {
+		return model.DoTaskRequest{}, err
+	}
+
+	debugNow, err := h.prepareDebugNow(r)
+	if err != nil {
+		return model.DoTaskRequest{}, err
+	}
+
 	return model.DoTaskRequest{
-		TaskId: r.URL.Query().Get("id"),
+		TaskId:   r.URL.Query().Get("id"),
+		Timezone: timezone,
+		DebugNow: debugNow,
+		CatchUp:  r.URL.Query().Get("catchup"),
 	}, nil
 }
 
+// prepareTimezone получает часовой пояс запроса из заголовка X-Timezone, без заголовка используется часовой пояс планировщика
+func (h *SchedulerHandler) prepareTimezone(r *http.Request) (*time.Location, error) {
+	timezoneName := r.Header.Get(model.TimezoneHeader)
+	if timezoneName == "" {
+		return nil, nil
+	}
+
+	timezone, err := time.LoadLocation(timezoneName)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс %s: %s", timezoneName, err.Error())
+	}
+
+	return timezone, nil
+}
+
+// prepareDebugNow получает подменённый текущий момент из заголовка X-Debug-Now, права на него проверяет auth.Middleware
+func (h *SchedulerHandler) prepareDebugNow(r *http.Request) (time.Time, error) {
+	debugNowStr := r.Header.Get(model.DebugNowHeader)
+	if debugNowStr == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{model.DateTimeFormat, model.CommonDateFormat} {
+		if debugNow, err := time.Parse(layout, debugNowStr); err == nil {
+			return debugNow, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("некорректный момент времени %s в заголовке %s", debugNowStr, model.DebugNowHeader)
+}
+
 func (h *SchedulerHandler) prepareTaskResponse(w http.ResponseWriter, taskResponse any, httpStatus int) {
 	encoderErr := json.NewEncoder(w).Encode(&taskResponse)
 	if encoderErr != nil {
@@ -289,15 +831,16 @@ func (h *SchedulerHandler) prepareTaskResponse(w http.ResponseWriter, taskRespon
 }
 
 func (h *SchedulerHandler) prepareGetClosestTasksRequest(r *http.Request) (model.ClosestTasksRequest, error) {
+	lang := h.prepareLang(r)
 	searchVal := r.URL.Query().Get("search")
 	if searchVal == "" {
-		return model.ClosestTasksRequest{}, nil
+		return model.ClosestTasksRequest{Lang: lang}, nil
 	}
 
 	searchDate, err := time.Parse(model.SearchDateFormat, searchVal)
 	if err != nil {
-		return model.ClosestTasksRequest{SearchTitle: searchVal}, nil
+		return model.ClosestTasksRequest{SearchTitle: searchVal, Lang: lang}, nil
 	}
 
-	return model.ClosestTasksRequest{SearchDate: searchDate}, nil
+	return model.ClosestTasksRequest{SearchDate: searchDate, Lang: lang}, nil
 }
@@ -1,15 +1,46 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
+	"go_final_project/service/model"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Хранилища, которые выбираются схемой DSN в TODO_DBFILE, для SQLite это ещё и имя драйвера database/sql
+const (
+	StorageSQLite  = "sqlite"
+	StorageSQLite3 = "sqlite3"
+	StorageMemory  = "memory"
+)
+
+// StorageDrivers пакеты драйверов SQLite, sqlite3 доступен только в сборке с cgo
+var StorageDrivers = map[string]string{
+	StorageSQLite:  "modernc.org/sqlite",
+	StorageSQLite3: "github.com/mattn/go-sqlite3",
+}
+
 type Config struct {
 	Port string
+	// DB строка подключения для драйвера SQLite без схемы DSN: путь к файлу и, возможно, параметры после ?
 	DB   string
 	Pass string
+	// Storage где хранятся задачи: StorageSQLite, StorageSQLite3 или StorageMemory
+	Storage string
+	// TrashRetention сколько удалённые задачи хранятся в корзине до окончательного удаления
+	TrashRetention time.Duration
+	// Workdays номера рабочих дней недели, где понедельник - 1, а воскресенье - 7
+	Workdays []int
+	// LeapDay на какую дату по умолчанию переносится 29 февраля в невисокосный год: feb28 или mar1
+	LeapDay string
+	// Timezone часовой пояс планировщика, по которому определяется сегодняшняя дата
+	Timezone *time.Location
+	// Now зафиксированный текущий момент для тестовых и демонстрационных стендов, нулевой - используются системные часы
+	Now time.Time
 }
 
 func LoadConfig() *Config {
@@ -17,10 +48,21 @@ func LoadConfig() *Config {
 		log.Println("Не удалось загрузить .env, используем переменные окружения")
 	}
 
+	timezone := getEnvTimezone("TODO_TIMEZONE", "Local")
+	storage, db := getEnvDSN("TODO_DBFILE", "scheduler.db")
+
 	return &Config{
 		Port: getEnv("TODO_PORT", "8080"),
-		DB:   getEnv("TODO_DBFILE", "scheduler.db"),
+		DB:   db,
 		Pass: getEnv("TODO_PASSWORD", ""),
+
+		Storage:        storage,
+		TrashRetention: getEnvDuration("TODO_TRASH_RETENTION", 30*24*time.Hour),
+
+		Workdays: getEnvWeekdays("TODO_WORKDAYS", []int{1, 2, 3, 4, 5}),
+		LeapDay:  getEnvLeapDay("TODO_LEAP_DAY", "mar1"),
+		Timezone: timezone,
+		Now:      getEnvNow("TODO_NOW", timezone),
 	}
 }
 
@@ -31,3 +73,108 @@ func getEnv(key, defaultVal string) string {
 
 	return defaultVal
 }
+
+func getEnvWeekdays(key string, defaultVal []int) []int {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultVal
+	}
+
+	var weekdays []int
+	for _, weekdayStr := range strings.Split(value, ",") {
+		weekday, err := strconv.Atoi(strings.TrimSpace(weekdayStr))
+		if err != nil || weekday < 1 || weekday > 7 {
+			log.Fatalf("Некорректный день недели %q в %s", weekdayStr, key)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+
+	return weekdays
+}
+
+func getEnvLeapDay(key, defaultVal string) string {
+	value := getEnv(key, defaultVal)
+	if value != "feb28" && value != "mar1" {
+		log.Fatalf("Некорректное значение %q в %s, допустимо feb28 или mar1", value, key)
+	}
+
+	return value
+}
+
+func getEnvTimezone(key, defaultVal string) *time.Location {
+	value := getEnv(key, defaultVal)
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		log.Fatalf("Некорректный часовой пояс %q в %s: %s", value, key, err)
+	}
+
+	return location
+}
+
+// getEnvNow читает зафиксированный текущий момент по часам часового пояса планировщика
+func getEnvNow(key string, location *time.Location) time.Time {
+	value := getEnv(key, "")
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range []string{model.DateTimeFormat, model.CommonDateFormat} {
+		if now, err := time.ParseInLocation(layout, value, location); err == nil {
+			return now
+		}
+	}
+	log.Fatalf("Некорректный момент времени %q в %s, ожидается формат %s или %s", value, key, model.DateTimeFormat, model.CommonDateFormat)
+
+	return time.Time{}
+}
+
+// getEnvDuration читает длительность в формате time.ParseDuration (например, 12h) или в днях (например, 30d)
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultVal
+	}
+
+	if daysStr, found := strings.CutSuffix(value, "d"); found {
+		days, err := strconv.Atoi(daysStr)
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+	} else if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration
+	}
+	log.Fatalf("Некорректная длительность %q в %s, ожидается, например, 30d или 12h", value, key)
+
+	return defaultVal
+}
+
+func getEnvDSN(key, defaultVal string) (string, string) {
+	value := getEnv(key, defaultVal)
+	storage, source, err := ParseDSN(value)
+	if err != nil {
+		log.Fatalf("Некорректная строка подключения %q в %s: %s", value, key, err.Error())
+	}
+
+	return storage, source
+}
+
+// ParseDSN разбирает строку подключения вида sqlite://путь?параметры, sqlite3://путь?параметры или memory://
+// и возвращает хранилище и строку подключения для драйвера. Путь без схемы открывается драйвером sqlite
+func ParseDSN(dsn string) (string, string, error) {
+	scheme, source, found := strings.Cut(dsn, "://")
+	if !found {
+		return StorageSQLite, dsn, nil
+	}
+
+	switch scheme {
+	case StorageSQLite, StorageSQLite3:
+		return scheme, source, nil
+	case StorageMemory:
+		if source != "" {
+			return "", "", fmt.Errorf("у хранилища в памяти нет параметров, получено %q", source)
+		}
+		return StorageMemory, "", nil
+	default:
+		return "", "", fmt.Errorf("неизвестная схема %s, допустимо %s://, %s:// или %s://", scheme, StorageSQLite, StorageSQLite3, StorageMemory)
+	}
+}
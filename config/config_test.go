@@ -0,0 +1,34 @@
+package config_test
+
+import (
+	"go_final_project/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDSN(t *testing.T) {
+	tbl := []struct {
+		dsn     string
+		storage string
+		source  string
+	}{
+		{"scheduler.db", config.StorageSQLite, "scheduler.db"},
+		{"../data/scheduler.db", config.StorageSQLite, "../data/scheduler.db"},
+		{"sqlite://scheduler.db?_pragma=busy_timeout(5000)", config.StorageSQLite, "scheduler.db?_pragma=busy_timeout(5000)"},
+		{"sqlite3:///var/lib/todo/scheduler.db?_journal_mode=WAL", config.StorageSQLite3, "/var/lib/todo/scheduler.db?_journal_mode=WAL"},
+		{"memory://", config.StorageMemory, ""},
+		{"memory://scheduler.db", "", ""},
+		{"postgres://localhost/todo", "", ""},
+	}
+	for _, v := range tbl {
+		storage, source, err := config.ParseDSN(v.dsn)
+		if v.storage == "" {
+			assert.Error(t, err, "строка подключения %q", v.dsn)
+			continue
+		}
+		assert.NoError(t, err, "строка подключения %q", v.dsn)
+		assert.Equal(t, v.storage, storage, "строка подключения %q", v.dsn)
+		assert.Equal(t, v.source, source, "строка подключения %q", v.dsn)
+	}
+}
@@ -4,10 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"go_final_project/service/model"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const taskColumns = "id, date, time, title, comment, repeat, repeat_until, repeat_count, deleted_at"
+
+// execer общая часть *sql.DB и *sql.Tx, чтобы запрос выполнялся и сам по себе, и внутри транзакции
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 type DBStorage struct {
 	Client *sql.DB
 }
@@ -16,38 +24,15 @@ func NewDBStorage(db *sql.DB) *DBStorage {
 	return &DBStorage{Client: db}
 }
 
-func (db *DBStorage) CreateTableScheduler() error {
-	createTableScheduler := `CREATE TABLE IF NOT EXISTS scheduler (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			date CHAR(8) NOT NULL DEFAULT "",
-			title VARCHAR(256) NOT NULL DEFAULT "",
-			comment VARCHAR(256) NOT NULL DEFAULT "",
-			repeat VARCHAR(128) NOT NULL DEFAULT ""
-		);`
-
-	_, err := db.Client.Exec(createTableScheduler)
-	if err != nil {
-		return fmt.Errorf("Ошибка создания таблицы scheduler в базе данных: %s", err)
-	}
-
-	createIndexColumnDate := "CREATE INDEX scheduler_date ON scheduler (date);"
-
-	_, err = db.Client.Exec(createIndexColumnDate)
-	if err != nil {
-		return fmt.Errorf("Ошибка создания таблицы индекса для колонки date: %s", err)
-	}
-
-	return nil
-}
-
 func (db *DBStorage) AddTask(taskToAdd Task) (Task, error) {
 	addTaskSQL := `INSERT INTO scheduler (
-		date, title, comment, repeat
+		date, time, title, comment, repeat, repeat_until, repeat_count
 		) VALUES (
-		?, ?, ?, ?
+		?, ?, ?, ?, ?, ?, ?
 	);`
 
-	addingRes, errRes := db.Client.Exec(addTaskSQL, taskToAdd.Date, taskToAdd.Title, taskToAdd.Comment, taskToAdd.Repeat)
+	addingRes, errRes := db.Client.Exec(addTaskSQL, taskToAdd.Date, taskToAdd.Time, taskToAdd.Title, taskToAdd.Comment, taskToAdd.Repeat,
+		taskToAdd.RepeatUntil, taskToAdd.RepeatCount)
 	if errRes != nil {
 		return Task{}, fmt.Errorf("ошибка сохранения задания в таблице scheduler: %s", errRes)
 	}
@@ -61,9 +46,14 @@ func (db *DBStorage) AddTask(taskToAdd Task) (Task, error) {
 }
 
 func (db *DBStorage) PutTask(taskToSave Task) error {
-	putTaskSQL := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?;`
+	return putTask(db.Client, taskToSave)
+}
 
-	updateRes, errRes := db.Client.Exec(putTaskSQL, taskToSave.Date, taskToSave.Title, taskToSave.Comment, taskToSave.Repeat, taskToSave.Id)
+func putTask(exec execer, taskToSave Task) error {
+	putTaskSQL := `UPDATE scheduler SET date = ?, time = ?, title = ?, comment = ?, repeat = ?, repeat_until = ?, repeat_count = ? WHERE id = ? AND deleted_at = '';`
+
+	updateRes, errRes := exec.Exec(putTaskSQL, taskToSave.Date, taskToSave.Time, taskToSave.Title, taskToSave.Comment, taskToSave.Repeat,
+		taskToSave.RepeatUntil, taskToSave.RepeatCount, taskToSave.Id)
 	if errRes != nil {
 		return fmt.Errorf("ошибка сохранения задания в таблице scheduler: %s", errRes.Error())
 	}
@@ -79,7 +69,8 @@ func (db *DBStorage) PutTask(taskToSave Task) error {
 }
 
 func (db *DBStorage) GetTasks(searchTitle string, searchDate time.Time) ([]Task, error) {
-	var partsForWhere []string
+	//Задачи в корзине не показываются
+	partsForWhere := []string{"deleted_at = ''"}
 	var binds []any
 	var dateStr string
 
@@ -95,22 +86,32 @@ func (db *DBStorage) GetTasks(searchTitle string, searchDate time.Time) ([]Task,
 	}
 
 	whereSQL := strings.Join(partsForWhere, " AND ")
-	if whereSQL != "" {
-		whereSQL = fmt.Sprintf("WHERE %s", whereSQL)
-	}
 
-	var tasks []Task
-	getTasksSQL := fmt.Sprintf("SELECT * FROM scheduler %s ORDER BY date ASC LIMIT ?;", whereSQL)
+	getTasksSQL := fmt.Sprintf("SELECT %s FROM scheduler WHERE %s ORDER BY date ASC, time ASC LIMIT ?;", taskColumns, whereSQL)
 	binds = append(binds, model.LimitTasks)
-	rows, err := db.Client.Query(getTasksSQL, binds...)
+
+	return db.queryTasks(getTasksSQL, binds...)
+}
+
+// GetTrash задачи в корзине, сначала удалённые последними
+func (db *DBStorage) GetTrash() ([]Task, error) {
+	getTrashSQL := fmt.Sprintf("SELECT %s FROM scheduler WHERE deleted_at != '' ORDER BY deleted_at DESC, id DESC;", taskColumns)
+
+	return db.queryTasks(getTrashSQL)
+}
+
+func (db *DBStorage) queryTasks(query string, args ...any) ([]Task, error) {
+	rows, err := db.Client.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var tasks []Task
 	for rows.Next() {
 		var task Task
-		err := rows.Scan(&task.Id, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+		err := rows.Scan(&task.Id, &task.Date, &task.Time, &task.Title, &task.Comment, &task.Repeat, &task.RepeatUntil, &task.RepeatCount,
+			&task.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -127,9 +128,10 @@ func (db *DBStorage) GetTasks(searchTitle string, searchDate time.Time) ([]Task,
 func (db *DBStorage) GetTask(id string) (Task, error) {
 	var task Task
 
-	getTasksSQL := "SELECT * FROM scheduler WHERE id = ?;"
+	getTasksSQL := fmt.Sprintf("SELECT %s FROM scheduler WHERE id = ? AND deleted_at = '';", taskColumns)
 
-	err := db.Client.QueryRow(getTasksSQL, id).Scan(&task.Id, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+	err := db.Client.QueryRow(getTasksSQL, id).Scan(&task.Id, &task.Date, &task.Time, &task.Title, &task.Comment, &task.Repeat, &task.RepeatUntil, &task.RepeatCount,
+		&task.DeletedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return Task{}, fmt.Errorf("задача с ID %s не найдена", id)
@@ -139,20 +141,339 @@ func (db *DBStorage) GetTask(id string) (Task, error) {
 	return task, nil
 }
 
-func (db *DBStorage) DeleteTask(id string) error {
-	deleteTaskSQL := "DELETE FROM scheduler WHERE id = ?;"
+// TrashTask переносит задачу в корзину, её исключения и пропущенные повторения сохраняются до очистки корзины
+func (db *DBStorage) TrashTask(id string, deletedAt string) error {
+	return trashTask(db.Client, id, deletedAt)
+}
+
+func trashTask(exec execer, id string, deletedAt string) error {
+	trashTaskSQL := "UPDATE scheduler SET deleted_at = ? WHERE id = ? AND deleted_at = '';"
 
-	deleteRes, errRes := db.Client.Exec(deleteTaskSQL, id)
+	trashRes, errRes := exec.Exec(trashTaskSQL, deletedAt, id)
 	if errRes != nil {
 		return fmt.Errorf("ошибка удаления задания в таблице scheduler: %s", errRes.Error())
 	}
-	rowsUpdated, err := deleteRes.RowsAffected()
+	rowsUpdated, err := trashRes.RowsAffected()
 	if rowsUpdated == 0 {
 		if err != nil {
 			return fmt.Errorf("не удалось удалить запись с ID %s из-за ошибки %s", id, err.Error())
 		}
-		return fmt.Errorf("не удалось обновить запись с ID %s", id)
+		return fmt.Errorf("задача с ID %s не найдена", id)
+	}
+
+	return nil
+}
+
+func (db *DBStorage) RestoreTask(id string) error {
+	restoreTaskSQL := "UPDATE scheduler SET deleted_at = '' WHERE id = ? AND deleted_at != '';"
+
+	restoreRes, errRes := db.Client.Exec(restoreTaskSQL, id)
+	if errRes != nil {
+		return fmt.Errorf("ошибка восстановления задания в таблице scheduler: %s", errRes.Error())
+	}
+	rowsUpdated, err := restoreRes.RowsAffected()
+	if rowsUpdated == 0 {
+		if err != nil {
+			return fmt.Errorf("не удалось восстановить запись с ID %s из-за ошибки %s", id, err.Error())
+		}
+		return fmt.Errorf("задача с ID %s не найдена в корзине", id)
+	}
+
+	return nil
+}
+
+// PurgeTrash окончательно удаляет задачи, попавшие в корзину раньше deletedBefore, вместе с их исключениями
+// и пропущенными повторениями, история выполнения остаётся
+func (db *DBStorage) PurgeTrash(deletedBefore string) (int, error) {
+	tx, err := db.Client.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось начать транзакцию: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	trashedSQL := "SELECT id FROM scheduler WHERE deleted_at != '' AND deleted_at < ?"
+	for _, table := range []string{"scheduler_exceptions", "scheduler_missed"} {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE task_id IN (%s);", table, trashedSQL), deletedBefore)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка очистки таблицы %s: %s", table, err.Error())
+		}
+	}
+
+	purgeRes, err := tx.Exec("DELETE FROM scheduler WHERE deleted_at != '' AND deleted_at < ?;", deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки корзины: %s", err.Error())
+	}
+	purged, err := purgeRes.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки корзины: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("не удалось очистить корзину: %s", err.Error())
+	}
+
+	return int(purged), nil
+}
+
+func (db *DBStorage) GetTaskExceptions(taskId string) ([]string, error) {
+	rows, err := db.Client.Query("SELECT date FROM scheduler_exceptions WHERE task_id = ? ORDER BY date ASC;", taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dates, nil
+}
+
+func (db *DBStorage) AddTaskExceptions(taskId string, dates []string) error {
+	addExceptionSQL := "INSERT OR IGNORE INTO scheduler_exceptions (task_id, date) VALUES (?, ?);"
+	for _, date := range dates {
+		if _, err := db.Client.Exec(addExceptionSQL, taskId, date); err != nil {
+			return fmt.Errorf("ошибка сохранения исключения %s для задания с ID %s: %s", date, taskId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (db *DBStorage) DeleteTaskException(taskId string, date string) error {
+	deleteRes, errRes := db.Client.Exec("DELETE FROM scheduler_exceptions WHERE task_id = ? AND date = ?;", taskId, date)
+	if errRes != nil {
+		return fmt.Errorf("ошибка удаления исключения в таблице scheduler_exceptions: %s", errRes.Error())
+	}
+	rowsDeleted, err := deleteRes.RowsAffected()
+	if rowsDeleted == 0 {
+		if err != nil {
+			return fmt.Errorf("не удалось удалить исключение %s для задания с ID %s: %s", date, taskId, err.Error())
+		}
+		return fmt.Errorf("исключение %s для задания с ID %s не найдено", date, taskId)
 	}
 
 	return nil
 }
+
+func (db *DBStorage) GetTaskMissed(taskId string) ([]Missed, error) {
+	rows, err := db.Client.Query("SELECT date, time FROM scheduler_missed WHERE task_id = ? ORDER BY date ASC, time ASC;", taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var missed []Missed
+	for rows.Next() {
+		var occurrence Missed
+		if err := rows.Scan(&occurrence.Date, &occurrence.Time); err != nil {
+			return nil, err
+		}
+		missed = append(missed, occurrence)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return missed, nil
+}
+
+// AddTaskMissed записывает пропущенные повторения задачи одной транзакцией
+func (db *DBStorage) AddTaskMissed(taskId string, missed []Missed) error {
+	tx, err := db.Client.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	addMissedSQL := "INSERT OR IGNORE INTO scheduler_missed (task_id, date, time) VALUES (?, ?, ?);"
+	for _, occurrence := range missed {
+		if _, err := tx.Exec(addMissedSQL, taskId, occurrence.Date, occurrence.Time); err != nil {
+			return fmt.Errorf("ошибка сохранения пропущенного повторения %s для задания с ID %s: %s", occurrence.Date, taskId, err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось сохранить пропущенные повторения: %s", err.Error())
+	}
+
+	return nil
+}
+
+const completionColumns = "id, task_id, title, date, time, done_at, next_date, next_time"
+
+// CompleteTask в одной транзакции переносит задачу на следующее повторение nextTask или, если его нет,
+// в корзину со временем выполнения completion.DoneAt, и записывает выполнение в историю
+func (db *DBStorage) CompleteTask(completion Completion, nextTask *Task) error {
+	tx, err := db.Client.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	if nextTask == nil {
+		err = trashTask(tx, strconv.Itoa(completion.TaskId), completion.DoneAt)
+	} else {
+		err = putTask(tx, *nextTask)
+	}
+	if err != nil {
+		return err
+	}
+
+	addCompletionSQL := `INSERT INTO scheduler_completions (
+		task_id, title, date, time, done_at, next_date, next_time
+		) VALUES (
+		?, ?, ?, ?, ?, ?, ?
+	);`
+
+	_, err = tx.Exec(addCompletionSQL, completion.TaskId, completion.Title, completion.Date, completion.Time,
+		completion.DoneAt, completion.NextDate, completion.NextTime)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения выполнения задания с ID %d: %s", completion.TaskId, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось сохранить выполнение задания с ID %d: %s", completion.TaskId, err.Error())
+	}
+
+	return nil
+}
+
+// GetTaskCompletions история выполнения задачи, сначала последние выполнения
+func (db *DBStorage) GetTaskCompletions(taskId string) ([]Completion, error) {
+	return db.queryCompletions("SELECT "+completionColumns+" FROM scheduler_completions WHERE task_id = ? ORDER BY id DESC;", taskId)
+}
+
+// GetCompletions последние limit выполнений всех задач, сначала последние
+func (db *DBStorage) GetCompletions(limit int) ([]Completion, error) {
+	return db.queryCompletions("SELECT "+completionColumns+" FROM scheduler_completions ORDER BY id DESC LIMIT ?;", limit)
+}
+
+func (db *DBStorage) queryCompletions(query string, args ...any) ([]Completion, error) {
+	rows, err := db.Client.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var completions []Completion
+	for rows.Next() {
+		var completion Completion
+		err := rows.Scan(&completion.Id, &completion.TaskId, &completion.Title, &completion.Date, &completion.Time,
+			&completion.DoneAt, &completion.NextDate, &completion.NextTime)
+		if err != nil {
+			return nil, err
+		}
+		completions = append(completions, completion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return completions, nil
+}
+
+func (db *DBStorage) GetHolidays(year int) ([]Holiday, error) {
+	getHolidaysSQL := "SELECT date, title FROM holidays ORDER BY date ASC;"
+	var binds []any
+	if year > 0 {
+		getHolidaysSQL = "SELECT date, title FROM holidays WHERE date LIKE ? ORDER BY date ASC;"
+		binds = append(binds, fmt.Sprintf("%04d%%", year))
+	}
+
+	rows, err := db.Client.Query(getHolidaysSQL, binds...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holidays []Holiday
+	for rows.Next() {
+		var holiday Holiday
+		if err := rows.Scan(&holiday.Date, &holiday.Title); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, holiday)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+// SaveHolidays добавляет праздники или обновляет названия уже существующих одной транзакцией
+func (db *DBStorage) SaveHolidays(holidays []Holiday) error {
+	tx, err := db.Client.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	saveHolidaySQL := "INSERT INTO holidays (date, title) VALUES (?, ?) ON CONFLICT (date) DO UPDATE SET title = excluded.title;"
+	for _, holiday := range holidays {
+		if _, err := tx.Exec(saveHolidaySQL, holiday.Date, holiday.Title); err != nil {
+			return fmt.Errorf("ошибка сохранения праздника %s в таблице holidays: %s", holiday.Date, err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось сохранить праздники: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (db *DBStorage) PutHoliday(holiday Holiday) error {
+	updateRes, errRes := db.Client.Exec("UPDATE holidays SET title = ? WHERE date = ?;", holiday.Title, holiday.Date)
+	if errRes != nil {
+		return fmt.Errorf("ошибка сохранения праздника в таблице holidays: %s", errRes.Error())
+	}
+	rowsUpdated, err := updateRes.RowsAffected()
+	if rowsUpdated == 0 {
+		if err != nil {
+			return fmt.Errorf("не удалось обновить праздник %s: %s", holiday.Date, err.Error())
+		}
+		return fmt.Errorf("праздник %s не найден", holiday.Date)
+	}
+
+	return nil
+}
+
+func (db *DBStorage) DeleteHoliday(date string) error {
+	deleteRes, errRes := db.Client.Exec("DELETE FROM holidays WHERE date = ?;", date)
+	if errRes != nil {
+		return fmt.Errorf("ошибка удаления праздника в таблице holidays: %s", errRes.Error())
+	}
+	rowsDeleted, err := deleteRes.RowsAffected()
+	if rowsDeleted == 0 {
+		if err != nil {
+			return fmt.Errorf("не удалось удалить праздник %s: %s", date, err.Error())
+		}
+		return fmt.Errorf("праздник %s не найден", date)
+	}
+
+	return nil
+}
+
+func (db *DBStorage) IsHoliday(date time.Time) (bool, error) {
+	var holidaysCnt int
+	err := db.Client.QueryRow("SELECT count(date) FROM holidays WHERE date = ?;", date.Format(model.CommonDateFormat)).Scan(&holidaysCnt)
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить праздничный день: %s", err.Error())
+	}
+
+	return holidaysCnt > 0, nil
+}
@@ -0,0 +1,398 @@
+package database
+
+import (
+	"fmt"
+	"go_final_project/service/model"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage хранилище в памяти с той же логикой, что и DBStorage, безопасно для одновременного использования.
+// Данные пропадают при остановке приложения
+type MemoryStorage struct {
+	mu         sync.RWMutex
+	lastId     int
+	tasks      map[int]Task
+	exceptions map[string]map[string]bool
+	missed     map[string]map[Missed]bool
+	holidays   map[string]string
+	// completions история выполнения в порядке добавления
+	completions []Completion
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		tasks:      make(map[int]Task),
+		exceptions: make(map[string]map[string]bool),
+		missed:     make(map[string]map[Missed]bool),
+		holidays:   make(map[string]string),
+	}
+}
+
+func (m *MemoryStorage) AddTask(taskToAdd Task) (Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastId++
+	taskToAdd.Id = m.lastId
+	m.tasks[taskToAdd.Id] = taskToAdd
+
+	return taskToAdd, nil
+}
+
+func (m *MemoryStorage) PutTask(taskToSave Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if task, ok := m.tasks[taskToSave.Id]; !ok || task.DeletedAt != "" {
+		return fmt.Errorf("не удалось обновить запись с ID %d", taskToSave.Id)
+	}
+	m.tasks[taskToSave.Id] = taskToSave
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTasks(searchTitle string, searchDate time.Time) ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var dateStr string
+	if !searchDate.IsZero() {
+		dateStr = searchDate.Format(model.CommonDateFormat)
+	}
+
+	var tasks []Task
+	for _, task := range m.tasks {
+		if task.DeletedAt != "" {
+			continue
+		}
+		if searchTitle != "" && !matchLike(task.Title, "%"+searchTitle+"%") {
+			continue
+		}
+		if dateStr != "" && task.Date != dateStr {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	//Как ORDER BY date ASC, time ASC в SQLite, задачи с одинаковыми датой и временем идут в порядке добавления
+	slices.SortFunc(tasks, func(a, b Task) int {
+		if a.Date != b.Date {
+			return strings.Compare(a.Date, b.Date)
+		}
+		if a.Time != b.Time {
+			return strings.Compare(a.Time, b.Time)
+		}
+		return a.Id - b.Id
+	})
+	if len(tasks) > model.LimitTasks {
+		tasks = tasks[:model.LimitTasks]
+	}
+
+	return tasks, nil
+}
+
+func (m *MemoryStorage) GetTask(id string) (Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	taskId, err := strconv.Atoi(id)
+	task, ok := m.tasks[taskId]
+	if err != nil || !ok || task.DeletedAt != "" {
+		return Task{}, fmt.Errorf("задача с ID %s не найдена", id)
+	}
+
+	return task, nil
+}
+
+func (m *MemoryStorage) TrashTask(id string, deletedAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taskId, err := strconv.Atoi(id)
+	task, ok := m.tasks[taskId]
+	if err != nil || !ok || task.DeletedAt != "" {
+		return fmt.Errorf("задача с ID %s не найдена", id)
+	}
+	task.DeletedAt = deletedAt
+	m.tasks[taskId] = task
+
+	return nil
+}
+
+func (m *MemoryStorage) RestoreTask(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taskId, err := strconv.Atoi(id)
+	task, ok := m.tasks[taskId]
+	if err != nil || !ok || task.DeletedAt == "" {
+		return fmt.Errorf("задача с ID %s не найдена в корзине", id)
+	}
+	task.DeletedAt = ""
+	m.tasks[taskId] = task
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTrash() ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tasks []Task
+	for _, task := range m.tasks {
+		if task.DeletedAt != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b Task) int {
+		if a.DeletedAt != b.DeletedAt {
+			return strings.Compare(b.DeletedAt, a.DeletedAt)
+		}
+		return b.Id - a.Id
+	})
+
+	return tasks, nil
+}
+
+func (m *MemoryStorage) PurgeTrash(deletedBefore string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int
+	for taskId, task := range m.tasks {
+		if task.DeletedAt == "" || task.DeletedAt >= deletedBefore {
+			continue
+		}
+		delete(m.tasks, taskId)
+		delete(m.exceptions, strconv.Itoa(taskId))
+		delete(m.missed, strconv.Itoa(taskId))
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (m *MemoryStorage) GetTaskExceptions(taskId string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var dates []string
+	for date := range m.exceptions[taskId] {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+
+	return dates, nil
+}
+
+func (m *MemoryStorage) AddTaskExceptions(taskId string, dates []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(dates) > 0 && m.exceptions[taskId] == nil {
+		m.exceptions[taskId] = make(map[string]bool)
+	}
+	for _, date := range dates {
+		m.exceptions[taskId][date] = true
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteTaskException(taskId string, date string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.exceptions[taskId][date] {
+		return fmt.Errorf("исключение %s для задания с ID %s не найдено", date, taskId)
+	}
+	delete(m.exceptions[taskId], date)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTaskMissed(taskId string) ([]Missed, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var missed []Missed
+	for occurrence := range m.missed[taskId] {
+		missed = append(missed, occurrence)
+	}
+	slices.SortFunc(missed, func(a, b Missed) int {
+		if a.Date != b.Date {
+			return strings.Compare(a.Date, b.Date)
+		}
+		return strings.Compare(a.Time, b.Time)
+	})
+
+	return missed, nil
+}
+
+func (m *MemoryStorage) AddTaskMissed(taskId string, missed []Missed) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(missed) > 0 && m.missed[taskId] == nil {
+		m.missed[taskId] = make(map[Missed]bool)
+	}
+	for _, occurrence := range missed {
+		m.missed[taskId][occurrence] = true
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) CompleteTask(completion Completion, nextTask *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[completion.TaskId]
+	if !ok || task.DeletedAt != "" {
+		return fmt.Errorf("задача с ID %d не найдена", completion.TaskId)
+	}
+	if nextTask == nil {
+		task.DeletedAt = completion.DoneAt
+	} else {
+		task = *nextTask
+	}
+	m.tasks[completion.TaskId] = task
+
+	completion.Id = len(m.completions) + 1
+	m.completions = append(m.completions, completion)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTaskCompletions(taskId string) ([]Completion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var completions []Completion
+	for i := len(m.completions) - 1; i >= 0; i-- {
+		if strconv.Itoa(m.completions[i].TaskId) == taskId {
+			completions = append(completions, m.completions[i])
+		}
+	}
+
+	return completions, nil
+}
+
+func (m *MemoryStorage) GetCompletions(limit int) ([]Completion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var completions []Completion
+	for i := len(m.completions) - 1; i >= 0 && len(completions) < limit; i-- {
+		completions = append(completions, m.completions[i])
+	}
+
+	return completions, nil
+}
+
+func (m *MemoryStorage) GetHolidays(year int) ([]Holiday, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	yearPrefix := fmt.Sprintf("%04d", year)
+	var holidays []Holiday
+	for date, title := range m.holidays {
+		if year > 0 && !strings.HasPrefix(date, yearPrefix) {
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: date, Title: title})
+	}
+	slices.SortFunc(holidays, func(a, b Holiday) int {
+		return strings.Compare(a.Date, b.Date)
+	})
+
+	return holidays, nil
+}
+
+func (m *MemoryStorage) SaveHolidays(holidays []Holiday) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, holiday := range holidays {
+		m.holidays[holiday.Date] = holiday.Title
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) PutHoliday(holiday Holiday) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.holidays[holiday.Date]; !ok {
+		return fmt.Errorf("праздник %s не найден", holiday.Date)
+	}
+	m.holidays[holiday.Date] = holiday.Title
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteHoliday(date string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.holidays[date]; !ok {
+		return fmt.Errorf("праздник %s не найден", date)
+	}
+	delete(m.holidays, date)
+
+	return nil
+}
+
+func (m *MemoryStorage) IsHoliday(date time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.holidays[date.Format(model.CommonDateFormat)]
+
+	return ok, nil
+}
+
+// matchLike проверяет строку по шаблону LIKE так же, как SQLite: % - любая подстрока, _ - любой символ,
+// регистр не учитывается только для латиницы
+func matchLike(value string, pattern string) bool {
+	valueRunes := []rune(foldASCII(value))
+	patternRunes := []rune(foldASCII(pattern))
+
+	//matched[j] - совпадает ли обработанная часть строки с первыми j символами шаблона
+	matched := make([]bool, len(patternRunes)+1)
+	matched[0] = true
+	for j := 1; j <= len(patternRunes) && patternRunes[j-1] == '%'; j++ {
+		matched[j] = true
+	}
+
+	for _, valueRune := range valueRunes {
+		next := make([]bool, len(patternRunes)+1)
+		for j, patternRune := range patternRunes {
+			switch patternRune {
+			case '%':
+				next[j+1] = next[j] || matched[j+1]
+			case '_':
+				next[j+1] = matched[j]
+			default:
+				next[j+1] = matched[j] && patternRune == valueRune
+			}
+		}
+		matched = next
+	}
+
+	return matched[len(patternRunes)]
+}
+
+func foldASCII(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, value)
+}
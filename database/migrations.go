@@ -0,0 +1,317 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Migration одна версия схемы базы данных. Up и Down выполняются в транзакции вместе с записью в schema_version.
+// Up не должна падать на файлах, созданных до появления миграций, где часть таблиц и колонок уже есть
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+	Down        func(tx *sql.Tx) error
+}
+
+// MigrationStatus состояние миграции в базе данных, AppliedAt пустое, если миграция не применена
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   string
+}
+
+// migrations все миграции по возрастанию версии, новые добавляются только в конец
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "таблица scheduler",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS scheduler (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				date CHAR(8) NOT NULL DEFAULT "",
+				title VARCHAR(256) NOT NULL DEFAULT "",
+				comment VARCHAR(256) NOT NULL DEFAULT "",
+				repeat VARCHAR(128) NOT NULL DEFAULT ""
+			);`,
+			"CREATE INDEX IF NOT EXISTS scheduler_date ON scheduler (date);",
+		),
+		Down: execStatements("DROP TABLE scheduler;"),
+	},
+	{
+		Version:     2,
+		Description: "условия окончания повторений repeat_until и repeat_count",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "scheduler", "repeat_until", `CHAR(8) NOT NULL DEFAULT ""`); err != nil {
+				return err
+			}
+			return addColumn(tx, "scheduler", "repeat_count", `INTEGER NOT NULL DEFAULT 0`)
+		},
+		Down: execStatements(
+			"ALTER TABLE scheduler DROP COLUMN repeat_until;",
+			"ALTER TABLE scheduler DROP COLUMN repeat_count;",
+		),
+	},
+	{
+		Version:     3,
+		Description: "таблица holidays",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS holidays (
+				date CHAR(8) PRIMARY KEY,
+				title VARCHAR(256) NOT NULL DEFAULT ""
+			);`,
+		),
+		Down: execStatements("DROP TABLE holidays;"),
+	},
+	{
+		Version:     4,
+		Description: "таблица scheduler_exceptions",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS scheduler_exceptions (
+				task_id INTEGER NOT NULL,
+				date CHAR(8) NOT NULL,
+				PRIMARY KEY (task_id, date)
+			);`,
+		),
+		Down: execStatements("DROP TABLE scheduler_exceptions;"),
+	},
+	{
+		Version:     5,
+		Description: "время задачи time",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "scheduler", "time", `CHAR(5) NOT NULL DEFAULT ""`)
+		},
+		Down: execStatements("ALTER TABLE scheduler DROP COLUMN time;"),
+	},
+	{
+		Version:     6,
+		Description: "таблица scheduler_missed",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS scheduler_missed (
+				task_id INTEGER NOT NULL,
+				date CHAR(8) NOT NULL,
+				time CHAR(5) NOT NULL DEFAULT "",
+				PRIMARY KEY (task_id, date, time)
+			);`,
+		),
+		Down: execStatements("DROP TABLE scheduler_missed;"),
+	},
+	{
+		Version:     7,
+		Description: "история выполнения задач scheduler_completions",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS scheduler_completions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				task_id INTEGER NOT NULL,
+				title VARCHAR(256) NOT NULL DEFAULT "",
+				date CHAR(8) NOT NULL DEFAULT "",
+				time CHAR(5) NOT NULL DEFAULT "",
+				done_at CHAR(14) NOT NULL DEFAULT "",
+				next_date CHAR(8) NOT NULL DEFAULT "",
+				next_time CHAR(5) NOT NULL DEFAULT ""
+			);`,
+			"CREATE INDEX IF NOT EXISTS scheduler_completions_task_id ON scheduler_completions (task_id);",
+		),
+		Down: execStatements("DROP TABLE scheduler_completions;"),
+	},
+	{
+		Version:     8,
+		Description: "корзина: время удаления задачи deleted_at",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "scheduler", "deleted_at", `CHAR(14) NOT NULL DEFAULT ""`)
+		},
+		//Прежние версии не знают о корзине, поэтому задачи из неё удаляются окончательно, как при очистке корзины.
+		//Строки scheduler_completions этих задач остаются намеренно: история выполнения переживает удаление задачи
+		//и по-прежнему доступна по task_id, а при откате ниже версии 7 удаляется вместе с таблицей
+		Down: execStatements(
+			"DELETE FROM scheduler_exceptions WHERE task_id IN (SELECT id FROM scheduler WHERE deleted_at != '');",
+			"DELETE FROM scheduler_missed WHERE task_id IN (SELECT id FROM scheduler WHERE deleted_at != '');",
+			"DELETE FROM scheduler WHERE deleted_at != '';",
+			"ALTER TABLE scheduler DROP COLUMN deleted_at;",
+		),
+	},
+}
+
+// LatestSchemaVersion версия схемы после применения всех миграций
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate применяет все ещё не применённые миграции
+func (db *DBStorage) Migrate() error {
+	return db.MigrateTo(LatestSchemaVersion())
+}
+
+// MigrateTo применяет или откатывает миграции, пока версия схемы не станет равна version, 0 - пустая база данных.
+// Каждая миграция выполняется в отдельной транзакции, при ошибке схема остаётся на последней успешной версии
+func (db *DBStorage) MigrateTo(version int) error {
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf("версия схемы %d не существует, последняя версия %d", version, LatestSchemaVersion())
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if migration.Version > current && migration.Version <= version {
+			if err := db.applyMigration(migration, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Version <= current && migration.Version > version {
+			if err := db.applyMigration(migration, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion текущая версия схемы, 0 - миграции ещё не применялись
+func (db *DBStorage) SchemaVersion() (int, error) {
+	if err := db.createTableSchemaVersion(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.Client.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version;").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("Ошибка получения версии схемы: %s", err)
+	}
+
+	return version, nil
+}
+
+// MigrationsStatus состояние всех миграций по возрастанию версии
+func (db *DBStorage) MigrationsStatus() ([]MigrationStatus, error) {
+	if err := db.createTableSchemaVersion(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Client.Query("SELECT version, applied_at FROM schema_version;")
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка получения применённых миграций: %s", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int]string)
+	for rows.Next() {
+		var (
+			version int
+			applied string
+		)
+		if err := rows.Scan(&version, &applied); err != nil {
+			return nil, fmt.Errorf("Ошибка получения применённых миграций: %s", err)
+		}
+		appliedAt[version] = applied
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Ошибка получения применённых миграций: %s", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   appliedAt[migration.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+func (db *DBStorage) createTableSchemaVersion() error {
+	createTableSchemaVersion := `CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at VARCHAR(32) NOT NULL DEFAULT ""
+		);`
+
+	_, err := db.Client.Exec(createTableSchemaVersion)
+	if err != nil {
+		return fmt.Errorf("Ошибка создания таблицы schema_version в базе данных: %s", err)
+	}
+
+	return nil
+}
+
+// applyMigration применяет миграцию (up) или откатывает её и в той же транзакции обновляет schema_version
+func (db *DBStorage) applyMigration(migration Migration, up bool) error {
+	tx, err := db.Client.Begin()
+	if err != nil {
+		return fmt.Errorf("Ошибка начала транзакции миграции %d: %s", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if up {
+		err = migration.Up(tx)
+		if err == nil {
+			_, err = tx.Exec("INSERT INTO schema_version (version, applied_at) VALUES (?, ?);",
+				migration.Version, time.Now().UTC().Format(time.RFC3339))
+		}
+	} else {
+		err = migration.Down(tx)
+		if err == nil {
+			_, err = tx.Exec("DELETE FROM schema_version WHERE version = ?;", migration.Version)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Ошибка миграции %d (%s): %s", migration.Version, migration.Description, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Ошибка сохранения миграции %d: %s", migration.Version, err)
+	}
+
+	return nil
+}
+
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addColumn добавляет колонку, если её ещё нет: в файлах, созданных до появления миграций, она может уже быть
+func addColumn(tx *sql.Tx, table string, name string, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk    int
+			columnName, colType string
+			defaultValue        sql.NullString
+		)
+		if err := rows.Scan(&cid, &columnName, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if columnName == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, name, definition))
+
+	return err
+}
@@ -0,0 +1,130 @@
+package database_test
+
+import (
+	"database/sql"
+	"fmt"
+	"go_final_project/database"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tableColumns(t *testing.T, db *sql.DB, table string) []string {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?);", table)
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		columns = append(columns, name)
+	}
+	assert.NoError(t, rows.Err())
+
+	return columns
+}
+
+// TestMigrateLegacyFile проверяет обновление файла, созданного до появления миграций
+func TestMigrateLegacyFile(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//Схема первых версий планировщика и колонка, добавленная позже без миграций
+	for _, statement := range []string{
+		`CREATE TABLE scheduler (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			date CHAR(8) NOT NULL DEFAULT "",
+			title VARCHAR(256) NOT NULL DEFAULT "",
+			comment VARCHAR(256) NOT NULL DEFAULT "",
+			repeat VARCHAR(128) NOT NULL DEFAULT ""
+		);`,
+		"CREATE INDEX scheduler_date ON scheduler (date);",
+		`ALTER TABLE scheduler ADD COLUMN repeat_until CHAR(8) NOT NULL DEFAULT "";`,
+		`INSERT INTO scheduler (date, title, repeat) VALUES ("20240126", "Старая задача", "d 5");`,
+	} {
+		_, err := db.Exec(statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dbStorage := database.NewDBStorage(db)
+	version, err := dbStorage.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	assert.NoError(t, dbStorage.Migrate())
+	version, err = dbStorage.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, database.LatestSchemaVersion(), version)
+	assert.Equal(t, []string{"id", "date", "title", "comment", "repeat", "repeat_until", "repeat_count", "time", "deleted_at"},
+		tableColumns(t, db, "scheduler"))
+
+	task, err := dbStorage.GetTask("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Старая задача", task.Title)
+	assert.Equal(t, "d 5", task.Repeat)
+
+	//Повторный запуск ничего не меняет
+	assert.NoError(t, dbStorage.Migrate())
+	statuses, err := dbStorage.MigrationsStatus()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, database.LatestSchemaVersion())
+	for _, status := range statuses {
+		assert.NotEmpty(t, status.AppliedAt, "миграция %d", status.Version)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	dbStorage := database.NewDBStorage(db)
+	assert.NoError(t, dbStorage.Migrate())
+	_, err = dbStorage.AddTask(database.Task{Date: "20240126", Time: "10:00", Title: "Задача"})
+	assert.NoError(t, err)
+
+	// откат корзины окончательно удаляет задачи из неё, история их выполнения остаётся
+	trashed, err := dbStorage.AddTask(database.Task{Date: "20240126", Title: "Выполненная задача"})
+	assert.NoError(t, err)
+	assert.NoError(t, dbStorage.CompleteTask(database.Completion{TaskId: trashed.Id, DoneAt: "20240126 10:00"}, nil))
+	assert.NoError(t, dbStorage.MigrateTo(7))
+	var tasksCount int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM scheduler;").Scan(&tasksCount))
+	assert.Equal(t, 1, tasksCount)
+	completions, err := dbStorage.GetTaskCompletions(fmt.Sprint(trashed.Id))
+	assert.NoError(t, err)
+	assert.Len(t, completions, 1)
+
+	assert.NoError(t, dbStorage.MigrateTo(4))
+	assert.NotContains(t, tableColumns(t, db, "scheduler"), "time")
+	assert.Empty(t, tableColumns(t, db, "scheduler_missed"))
+	assert.NotEmpty(t, tableColumns(t, db, "scheduler_exceptions"))
+	statuses, err := dbStorage.MigrationsStatus()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, statuses[3].AppliedAt)
+	assert.Empty(t, statuses[4].AppliedAt)
+
+	assert.NoError(t, dbStorage.Migrate())
+	task, err := dbStorage.GetTask("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Задача", task.Title)
+	assert.Equal(t, "", task.Time)
+
+	assert.NoError(t, dbStorage.MigrateTo(0))
+	assert.Empty(t, tableColumns(t, db, "scheduler"))
+	version, err := dbStorage.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	assert.Error(t, dbStorage.MigrateTo(database.LatestSchemaVersion()+1))
+	assert.Error(t, dbStorage.MigrateTo(-1))
+}
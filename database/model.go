@@ -1,9 +1,42 @@
 package database
 
 type Task struct {
-	Id      int
-	Date    string
+	Id   int
+	Date string
+	// Time время выполнения задачи в формате 15:04, пустая строка - в течение дня
+	Time    string
 	Title   string
 	Comment string
 	Repeat  string
+	// RepeatUntil дата, после которой задача больше не повторяется
+	RepeatUntil string
+	// RepeatCount сколько раз задача ещё должна быть выполнена, включая текущий, 0 - без ограничения
+	RepeatCount int
+	// DeletedAt когда задача перенесена в корзину, в формате model.DateTimeFormat, пустая строка - задача не удалена
+	DeletedAt string
+}
+
+// Missed пропущенное повторение просроченной задачи
+type Missed struct {
+	Date string
+	Time string
+}
+
+// Completion запись истории выполнения задачи, NextDate и NextTime пустые, если после выполнения задача удалена
+type Completion struct {
+	Id     int
+	TaskId int
+	Title  string
+	// Date и Time на когда задача была запланирована
+	Date string
+	Time string
+	// DoneAt когда задача фактически выполнена, в формате model.DateTimeFormat
+	DoneAt   string
+	NextDate string
+	NextTime string
+}
+
+type Holiday struct {
+	Date  string
+	Title string
 }
@@ -0,0 +1,48 @@
+package database
+
+import "time"
+
+// TaskRepository хранилище задач планировщика
+type TaskRepository interface {
+	AddTask(taskToAdd Task) (Task, error)
+	PutTask(taskToSave Task) error
+	GetTask(id string) (Task, error)
+	// GetTasks возвращает не больше model.LimitTasks задач, отсортированных по дате и времени
+	GetTasks(searchTitle string, searchDate time.Time) ([]Task, error)
+
+	// Задачи в корзине не возвращаются GetTask и GetTasks и не изменяются PutTask,
+	// окончательно задачи удаляются только очисткой корзины PurgeTrash
+	TrashTask(id string, deletedAt string) error
+	RestoreTask(id string) error
+	GetTrash() ([]Task, error)
+	PurgeTrash(deletedBefore string) (int, error)
+}
+
+// Storage всё, что хранит сервис: задачи с их исключениями, пропущенными повторениями и историей выполнения, а также праздники
+type Storage interface {
+	TaskRepository
+
+	GetTaskExceptions(taskId string) ([]string, error)
+	AddTaskExceptions(taskId string, dates []string) error
+	DeleteTaskException(taskId string, date string) error
+
+	GetTaskMissed(taskId string) ([]Missed, error)
+	AddTaskMissed(taskId string, missed []Missed) error
+
+	// CompleteTask атомарно переносит задачу на nextTask или, если nextTask нет, в корзину и записывает выполнение.
+	// История выполнения не удаляется вместе с задачей
+	CompleteTask(completion Completion, nextTask *Task) error
+	GetTaskCompletions(taskId string) ([]Completion, error)
+	GetCompletions(limit int) ([]Completion, error)
+
+	GetHolidays(year int) ([]Holiday, error)
+	SaveHolidays(holidays []Holiday) error
+	PutHoliday(holiday Holiday) error
+	DeleteHoliday(date string) error
+	IsHoliday(date time.Time) (bool, error)
+}
+
+var (
+	_ Storage = (*DBStorage)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)
@@ -0,0 +1,244 @@
+package database_test
+
+import (
+	"database/sql"
+	"fmt"
+	"go_final_project/database"
+	"go_final_project/service/model"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func newSQLiteStorage(t *testing.T) database.Storage {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	dbStorage := database.NewDBStorage(db)
+	if err := dbStorage.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return dbStorage
+}
+
+// TestStorages проверяет, что SQLite и хранилище в памяти ведут себя одинаково
+func TestStorages(t *testing.T) {
+	for name, newStorage := range map[string]func(t *testing.T) database.Storage{
+		"sqlite": newSQLiteStorage,
+		"memory": func(*testing.T) database.Storage { return database.NewMemoryStorage() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			storage := newStorage(t)
+
+			var ids []int
+			for i := 0; i < 12; i++ {
+				task, err := storage.AddTask(database.Task{
+					Date:  fmt.Sprintf("202401%02d", 20-i%3),
+					Time:  []string{"", "18:00", "09:30"}[i%2],
+					Title: []string{"Купить Pizza", "Позвонить маме", "купить молоко"}[i%3],
+				})
+				assert.NoError(t, err)
+				ids = append(ids, task.Id)
+			}
+			assert.Equal(t, 12, len(ids))
+			for i := 1; i < len(ids); i++ {
+				assert.Greater(t, ids[i], ids[i-1])
+			}
+
+			tasks, err := storage.GetTasks("", time.Time{})
+			assert.NoError(t, err)
+			assert.Len(t, tasks, model.LimitTasks)
+			for i := 1; i < len(tasks); i++ {
+				prev, cur := tasks[i-1], tasks[i]
+				assert.True(t, prev.Date < cur.Date || prev.Date == cur.Date && (prev.Time < cur.Time ||
+					prev.Time == cur.Time && prev.Id < cur.Id), "%v перед %v", prev, cur)
+			}
+
+			// LIKE в SQLite не учитывает регистр только для латиницы
+			for search, want := range map[string]int{"pizza": 4, "КУПИТЬ": 0, "купить": 4, "куп_ть": 4, "_упить": 8, "%": 10} {
+				tasks, err = storage.GetTasks(search, time.Time{})
+				assert.NoError(t, err)
+				assert.Len(t, tasks, want, "поиск %q", search)
+			}
+
+			date, err := time.Parse(model.CommonDateFormat, "20240119")
+			assert.NoError(t, err)
+			tasks, err = storage.GetTasks("маме", date)
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 4)
+
+			id := fmt.Sprint(ids[0])
+			task, err := storage.GetTask(id)
+			assert.NoError(t, err)
+			task.Title = "Купить хлеб"
+			assert.NoError(t, storage.PutTask(task))
+			task, err = storage.GetTask(id)
+			assert.NoError(t, err)
+			assert.Equal(t, "Купить хлеб", task.Title)
+
+			assert.NoError(t, storage.AddTaskExceptions(id, []string{"20240125", "20240122", "20240125"}))
+			exceptions, err := storage.GetTaskExceptions(id)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"20240122", "20240125"}, exceptions)
+			assert.NoError(t, storage.DeleteTaskException(id, "20240122"))
+			assert.Error(t, storage.DeleteTaskException(id, "20240122"))
+
+			assert.NoError(t, storage.AddTaskMissed(id, []database.Missed{{Date: "20240121", Time: "10:00"}, {Date: "20240120"}}))
+			missed, err := storage.GetTaskMissed(id)
+			assert.NoError(t, err)
+			assert.Equal(t, []database.Missed{{Date: "20240120"}, {Date: "20240121", Time: "10:00"}}, missed)
+
+			// выполнение переносит задачу на следующую дату, а последнее - в корзину
+			for _, nextDate := range []string{"20240127", "20240128", ""} {
+				completion := database.Completion{TaskId: ids[0], Title: task.Title, Date: task.Date,
+					DoneAt: "20240126 10:00", NextDate: nextDate}
+				var nextTask *database.Task
+				if nextDate != "" {
+					task.Date = nextDate
+					nextTask = &task
+				}
+				assert.NoError(t, storage.CompleteTask(completion, nextTask))
+			}
+			_, err = storage.GetTask(id)
+			assert.Error(t, err)
+			trash, err := storage.GetTrash()
+			assert.NoError(t, err)
+			assert.Len(t, trash, 1)
+			assert.Equal(t, "20240128", trash[0].Date)
+			assert.Equal(t, "20240126 10:00", trash[0].DeletedAt)
+			assert.Error(t, storage.CompleteTask(database.Completion{TaskId: ids[0], DoneAt: "20240126 10:30"}, nil))
+			task1, err := storage.GetTask(fmt.Sprint(ids[1]))
+			assert.NoError(t, err)
+			assert.NoError(t, storage.CompleteTask(database.Completion{TaskId: ids[1], DoneAt: "20240126 11:00"}, &task1))
+			completions, err := storage.GetTaskCompletions(id)
+			assert.NoError(t, err)
+			assert.Len(t, completions, 3)
+			assert.Equal(t, "", completions[0].NextDate)
+			assert.Equal(t, "20240127", completions[2].NextDate)
+			completions, err = storage.GetCompletions(2)
+			assert.NoError(t, err)
+			assert.Len(t, completions, 2)
+			assert.Equal(t, ids[1], completions[0].TaskId)
+			assert.Greater(t, completions[0].Id, completions[1].Id)
+
+			// очистка корзины удаляет задачу с исключениями и пропущенными повторениями, но не с историей
+			purged, err := storage.PurgeTrash("20240126 10:01")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, purged)
+			completions, err = storage.GetTaskCompletions(id)
+			assert.NoError(t, err)
+			assert.Len(t, completions, 3)
+			_, err = storage.GetTask(id)
+			assert.Error(t, err)
+			exceptions, err = storage.GetTaskExceptions(id)
+			assert.NoError(t, err)
+			assert.Empty(t, exceptions)
+			missed, err = storage.GetTaskMissed(id)
+			assert.NoError(t, err)
+			assert.Empty(t, missed)
+
+			assert.Error(t, storage.RestoreTask(id))
+			assert.Error(t, storage.PutTask(database.Task{Id: ids[0], Title: "Нет такой задачи"}))
+			_, err = storage.GetTask("abc")
+			assert.Error(t, err)
+
+			// корзина: задача не видна, но её исключения сохраняются до очистки
+			trashID := fmt.Sprint(ids[1])
+			assert.NoError(t, storage.AddTaskExceptions(trashID, []string{"20240201"}))
+			assert.NoError(t, storage.TrashTask(trashID, "20240126 10:00"))
+			assert.NoError(t, storage.TrashTask(fmt.Sprint(ids[2]), "20240120 10:00"))
+			assert.Error(t, storage.TrashTask(trashID, "20240126 11:00"))
+			_, err = storage.GetTask(trashID)
+			assert.Error(t, err)
+			assert.Error(t, storage.PutTask(database.Task{Id: ids[1], Title: "Задача в корзине"}))
+			tasks, err = storage.GetTasks("", time.Time{})
+			assert.NoError(t, err)
+			for _, task := range tasks {
+				assert.NotEqual(t, ids[1], task.Id)
+			}
+			trash, err = storage.GetTrash()
+			assert.NoError(t, err)
+			assert.Len(t, trash, 2)
+			assert.Equal(t, ids[1], trash[0].Id)
+			assert.Equal(t, "20240126 10:00", trash[0].DeletedAt)
+
+			assert.NoError(t, storage.RestoreTask(trashID))
+			assert.Error(t, storage.RestoreTask(trashID))
+			task, err = storage.GetTask(trashID)
+			assert.NoError(t, err)
+			assert.Empty(t, task.DeletedAt)
+			exceptions, err = storage.GetTaskExceptions(trashID)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"20240201"}, exceptions)
+
+			assert.NoError(t, storage.TrashTask(trashID, "20240126 10:00"))
+			purged, err = storage.PurgeTrash("20240126 10:00")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, purged)
+			trash, err = storage.GetTrash()
+			assert.NoError(t, err)
+			assert.Len(t, trash, 1)
+			assert.Error(t, storage.RestoreTask(fmt.Sprint(ids[2])))
+			purged, err = storage.PurgeTrash("20240126 10:01")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, purged)
+			exceptions, err = storage.GetTaskExceptions(trashID)
+			assert.NoError(t, err)
+			assert.Empty(t, exceptions)
+
+			assert.NoError(t, storage.SaveHolidays([]database.Holiday{{Date: "20250101", Title: "Новый год"}, {Date: "20240308", Title: "8 марта"}}))
+			assert.NoError(t, storage.SaveHolidays([]database.Holiday{{Date: "20240308", Title: "Международный женский день"}}))
+			holidays, err := storage.GetHolidays(2024)
+			assert.NoError(t, err)
+			assert.Equal(t, []database.Holiday{{Date: "20240308", Title: "Международный женский день"}}, holidays)
+			holidays, err = storage.GetHolidays(0)
+			assert.NoError(t, err)
+			assert.Len(t, holidays, 2)
+			assert.Error(t, storage.PutHoliday(database.Holiday{Date: "20240309", Title: "Нет такого праздника"}))
+			assert.NoError(t, storage.DeleteHoliday("20250101"))
+			assert.Error(t, storage.DeleteHoliday("20250101"))
+
+			date, err = time.Parse(model.CommonDateFormat, "20240308")
+			assert.NoError(t, err)
+			isHoliday, err := storage.IsHoliday(date)
+			assert.NoError(t, err)
+			assert.True(t, isHoliday)
+		})
+	}
+}
+
+func TestMemoryStorageConcurrent(t *testing.T) {
+	storage := database.NewMemoryStorage()
+
+	var wg sync.WaitGroup
+	ids := make(chan int, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := storage.AddTask(database.Task{Date: "20240126", Title: "Задача"})
+			assert.NoError(t, err)
+			ids <- task.Id
+			_, err = storage.GetTasks("Задача", time.Time{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := make(map[int]bool)
+	for id := range ids {
+		unique[id] = true
+	}
+	assert.Len(t, unique, 50)
+}
@@ -7,47 +7,88 @@ import (
 	"go_final_project/config"
 	"go_final_project/database"
 	"go_final_project/service"
+	"go_final_project/service/model"
 	"log"
 	_ "modernc.org/sqlite"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
-	dbFile := cfg.DB
-	if cfg.DB == "" {
-		appPath, err := os.Executable()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(cfg, os.Args[2:])
+		return
+	}
+
+	var storage database.Storage
+	switch cfg.Storage {
+	case config.StorageMemory:
+		storage = database.NewMemoryStorage()
+		log.Printf("Хранилище: %s, данные не сохраняются после остановки", cfg.Storage)
+	default:
+		db, dbStorage := openDBStorage(cfg)
+		defer db.Close()
+		storage = dbStorage
+		log.Printf("Хранилище: %s, драйвер %s, база данных %s", cfg.Storage, config.StorageDrivers[cfg.Storage], cfg.DB)
+	}
+
+	svc := service.NewService(storage, storage, cfg)
+	go runTrashPurge(svc)
+
+	appHandler := handler.NewSchedulerHandler(svc)
+	app := application.NewApplication(appHandler, cfg)
+	app.Start()
+}
+
+// runTrashPurge при запуске и затем раз в model.TrashPurgeInterval удаляет задачи, которые пролежали в корзине дольше срока хранения
+func runTrashPurge(svc *service.Service) {
+	for ; ; time.Sleep(model.TrashPurgeInterval) {
+		purged, err := svc.PurgeTrash()
 		if err != nil {
-			log.Fatal(err)
+			log.Printf("Ошибка очистки корзины: %s", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Из корзины окончательно удалено задач: %d", purged)
 		}
-		dbFile = filepath.Join(filepath.Dir(appPath), "scheduler.db")
 	}
+}
 
-	_, err := os.Stat(dbFile)
-	var install bool
-	if err != nil {
-		install = true
+// openDBStorage открывает файл базы данных SQLite и применяет к нему недостающие миграции
+func openDBStorage(cfg *config.Config) (*sql.DB, *database.DBStorage) {
+	db, dbStorage := openDB(cfg)
+
+	if err := dbStorage.Migrate(); err != nil {
+		log.Fatalf("Ошибка обновления схемы базы данных: %s", err)
 	}
 
-	db, err := sql.Open("sqlite", dbFile)
-	defer db.Close()
+	return db, dbStorage
+}
 
-	if err != nil {
-		log.Fatalf("Ошибка подключения к базе данных: %s", err)
+// openDB открывает файл базы данных SQLite драйвером, выбранным в строке подключения, без изменения схемы
+func openDB(cfg *config.Config) (*sql.DB, *database.DBStorage) {
+	if !slices.Contains(sql.Drivers(), cfg.Storage) {
+		log.Fatalf("Драйвер %s (%s) недоступен: приложение собрано без cgo", cfg.Storage, config.StorageDrivers[cfg.Storage])
 	}
 
-	dbStorage := database.NewDBStorage(db)
-	appHandler := handler.NewSchedulerHandler(service.NewService(dbStorage))
-
-	if install {
-		err := dbStorage.CreateTableScheduler()
+	dbFile := cfg.DB
+	if cfg.DB == "" {
+		appPath, err := os.Executable()
 		if err != nil {
-			log.Fatalf("Ошибка создания таблицы: %s", err)
+			log.Fatal(err)
 		}
+		dbFile = filepath.Join(filepath.Dir(appPath), "scheduler.db")
 	}
 
-	app := application.NewApplication(appHandler, cfg)
-	app.Start()
+	db, err := sql.Open(cfg.Storage, dbFile)
+	if err != nil {
+		log.Fatalf("Ошибка подключения к базе данных: %s", err)
+	}
+
+	return db, database.NewDBStorage(db)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"go_final_project/config"
+	"go_final_project/database"
+	"log"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `Использование:
+  migrate status            применённые и ожидающие миграции
+  migrate up [версия]       применить миграции до версии, по умолчанию до последней
+  migrate down [версия]     откатить миграции до версии, по умолчанию только последнюю применённую`
+
+// runMigrateCommand выполняет команду migrate над файлом базы данных из конфигурации
+func runMigrateCommand(cfg *config.Config, args []string) {
+	if cfg.Storage == config.StorageMemory {
+		log.Fatal("Миграции не нужны хранилищу в памяти")
+	}
+	if len(args) == 0 || len(args) > 2 {
+		log.Fatal(migrateUsage)
+	}
+
+	db, dbStorage := openDB(cfg)
+	defer db.Close()
+
+	current, err := dbStorage.SchemaVersion()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var target int
+	switch args[0] {
+	case "status":
+		if len(args) > 1 {
+			log.Fatal(migrateUsage)
+		}
+		printMigrationsStatus(dbStorage, current)
+		return
+	case "up":
+		target = database.LatestSchemaVersion()
+	case "down":
+		target = max(current-1, 0)
+	default:
+		log.Fatal(migrateUsage)
+	}
+
+	if len(args) == 2 {
+		target, err = strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatalf("Некорректная версия схемы %q", args[1])
+		}
+		if args[0] == "up" && target < current || args[0] == "down" && target > current {
+			log.Fatalf("Текущая версия схемы %d, для %s версия %d не подходит", current, args[0], target)
+		}
+	}
+
+	if err := dbStorage.MigrateTo(target); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stdout, "Версия схемы: %d -> %d\n", current, target)
+}
+
+func printMigrationsStatus(dbStorage *database.DBStorage, current int) {
+	statuses, err := dbStorage.MigrationsStatus()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Версия схемы: %d из %d\n", current, database.LatestSchemaVersion())
+	for _, status := range statuses {
+		applied := "не применена"
+		if status.AppliedAt != "" {
+			applied = "применена " + status.AppliedAt
+		}
+		fmt.Fprintf(os.Stdout, "%3d  %s: %s\n", status.Version, status.Description, applied)
+	}
+}
@@ -0,0 +1,24 @@
+package service
+
+import "time"
+
+// Clock источник текущего момента, от которого сервис считает сегодняшнюю дату и просроченные задачи
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock системные часы
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock часы, которые всегда показывают один и тот же момент, например на тестовом стенде
+type FixedClock struct {
+	Time time.Time
+}
+
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
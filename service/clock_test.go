@@ -0,0 +1,30 @@
+package service_test
+
+import (
+	"go_final_project/config"
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	svc := service.NewService(nil, nil, &config.Config{
+		Workdays: []int{1, 2, 3, 4, 5},
+		Timezone: moscow,
+		Now:      time.Date(2024, 1, 26, 23, 30, 0, 0, moscow),
+	})
+	assert.Equal(t, "20240126 23:30", svc.Now(nil).Format(model.DateTimeFormat))
+	assert.Equal(t, "20240126 20:30", svc.Now(time.UTC).Format(model.DateTimeFormat))
+	assert.Equal(t, "20240127 05:30", svc.Now(tokyo).Format(model.DateTimeFormat))
+
+	svc.SetClock(service.FixedClock{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)})
+	assert.Equal(t, "20240301 15:00", svc.Now(nil).Format(model.DateTimeFormat))
+}
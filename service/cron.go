@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_final_project/service/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField описание поля cron-выражения: название для сообщений об ошибках, диапазон и имена значений
+type cronField struct {
+	title string
+	min   int
+	max   int
+	names []string
+}
+
+var cronFields = []cronField{
+	{title: "минуты", min: 0, max: 59},
+	{title: "часы", min: 0, max: 23},
+	{title: "день месяца", min: 1, max: 31},
+	{title: "месяц", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	//Воскресенье в cron можно указать и как 0, и как 7
+	{title: "день недели", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// cronSchedule разобранное cron-выражение, минуты и часы проверяются, но при расчёте дат не учитываются
+type cronSchedule struct {
+	days       map[int]bool
+	months     map[int]bool
+	weekdays   map[int]bool
+	anyDay     bool
+	anyWeekday bool
+}
+
+// cronRule правило cron: по cron-выражению из 5 полей (минуты, часы, день месяца, месяц, день недели)
+type cronRule struct {
+	fields []string
+}
+
+func parseCronRule(values []string) (model.RepeatKind, error) {
+	return cronRule{fields: values}, nil
+}
+
+func (r cronRule) Validate() error {
+	if _, err := parseCronSchedule(r.fields); err != nil {
+		return fmt.Errorf("формат правила повторения для CRON не соблюден: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r cronRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	schedule, err := parseCronSchedule(r.fields)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	currentDate := request.Date
+	nowDate := startOfDay(request.Now)
+	isCurrentDatePast := currentDate.Before(nowDate)
+
+	//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты, и сегодняшний день тоже может подойти
+	if isCurrentDatePast {
+		currentDate = nowDate
+	}
+
+	return findNextDay(currentDate, isCurrentDatePast, schedule.isMatch)
+}
+
+func (r cronRule) String() string {
+	return "cron " + strings.Join(r.fields, " ")
+}
+
+func parseCronSchedule(fields []string) (cronSchedule, error) {
+	if len(fields) != len(cronFields) {
+		return cronSchedule{}, fmt.Errorf("cron-выражение должно состоять из %d полей, передано %d", len(cronFields), len(fields))
+	}
+
+	values := make([]map[int]bool, 0, len(fields))
+	for i, field := range fields {
+		fieldValues, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("поле cron-выражения «%s»: %s", cronFields[i].title, err.Error())
+		}
+		values = append(values, fieldValues)
+	}
+
+	weekdays := values[4]
+	if weekdays[7] {
+		weekdays[0] = true
+	}
+
+	return cronSchedule{
+		days:       values[2],
+		months:     values[3],
+		weekdays:   weekdays,
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField разбирает поле вида "*", "*/2", "5", "1-5", "1-10/3", "MON-FRI" и их списки через запятую
+func parseCronField(field string, fieldDesc cronField) (map[int]bool, error) {
+	if field == "" {
+		return nil, errors.New("поле не может быть пустым")
+	}
+
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("некорректный шаг %q", stepPart)
+			}
+		}
+
+		from, to := fieldDesc.min, fieldDesc.max
+		if rangePart != "*" {
+			fromPart, toPart, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			from, err = parseCronValue(fromPart, fieldDesc)
+			if err != nil {
+				return nil, err
+			}
+			to = from
+			if isRange {
+				to, err = parseCronValue(toPart, fieldDesc)
+				if err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				to = fieldDesc.max
+			}
+
+			if from > to {
+				return nil, fmt.Errorf("начало диапазона %d больше конца %d", from, to)
+			}
+		}
+
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseCronValue(valueStr string, fieldDesc cronField) (int, error) {
+	for i, name := range fieldDesc.names {
+		if strings.EqualFold(valueStr, name) {
+			return fieldDesc.min + i, nil
+		}
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное значение %q", valueStr)
+	}
+	if value < fieldDesc.min || value > fieldDesc.max {
+		return 0, fmt.Errorf("значение %d вне диапазона %d-%d", value, fieldDesc.min, fieldDesc.max)
+	}
+
+	return value, nil
+}
+
+// isMatch проверяет дату по правилам cron: если заданы и день месяца, и день недели, достаточно совпадения одного из них
+func (c cronSchedule) isMatch(date time.Time) bool {
+	if !c.months[int(date.Month())] {
+		return false
+	}
+
+	dayMatch := c.days[date.Day()]
+	weekdayMatch := c.weekdays[int(date.Weekday())]
+	if !c.anyDay && !c.anyWeekday {
+		return dayMatch || weekdayMatch
+	}
+
+	return dayMatch && weekdayMatch
+}
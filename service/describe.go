@@ -0,0 +1,403 @@
+package service
+
+import (
+	"fmt"
+	"go_final_project/service/model"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ruWeekdaysDative = []string{"понедельникам", "вторникам", "средам", "четвергам", "пятницам", "субботам", "воскресеньям"}
+	ruWeekdays       = []string{"понедельник", "вторник", "среду", "четверг", "пятницу", "субботу", "воскресенье"}
+	// ruWeekdayGenders род дня недели для согласования порядкового числительного: 0 - мужской, 1 - женский, 2 - средний
+	ruWeekdayGenders   = []int{0, 0, 1, 0, 1, 1, 2}
+	ruOrdinals         = [][]string{{"первый", "первую", "первое"}, {"второй", "вторую", "второе"}, {"третий", "третью", "третье"}, {"четвёртый", "четвёртую", "четвёртое"}, {"пятый", "пятую", "пятое"}}
+	ruLastOrdinals     = []string{"последний", "последнюю", "последнее"}
+	ruMonthsPrepos     = []string{"январе", "феврале", "марте", "апреле", "мае", "июне", "июле", "августе", "сентябре", "октябре", "ноябре", "декабре"}
+	ruMonthsGenitive   = []string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"}
+	enWeekdays         = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+	enMonths           = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	supportedLanguages = []string{model.LangRu, model.LangEn}
+)
+
+// IsSupportedLanguage проверяет, что для языка есть описание правил повторения
+func IsSupportedLanguage(lang string) bool {
+	return slices.Contains(supportedLanguages, lang)
+}
+
+// DescribeRepeatRule описывает правило повторения человеческим языком, например "1-го и последнего числа в феврале и августе"
+func DescribeRepeatRule(repeatRule model.RepeatRule, lang string) string {
+	if lang == model.LangEn {
+		return describeRepeatRuleEn(repeatRule) + describeShiftEn(repeatRule.Shift) + describeLeapEn(repeatRule.Leap) +
+			describeFromEn(repeatRule.From) + describeCatchUpEn(repeatRule.CatchUp)
+	}
+
+	return describeRepeatRuleRu(repeatRule) + describeShiftRu(repeatRule.Shift) + describeLeapRu(repeatRule.Leap) +
+		describeFromRu(repeatRule.From) + describeCatchUpRu(repeatRule.CatchUp)
+}
+
+func describeCatchUpRu(catchUp string) string {
+	switch catchUp {
+	case model.CatchUpStep:
+		return ", пропущенные повторения выполняются по одному"
+	case model.CatchUpRecord:
+		return ", пропущенные повторения записываются"
+	}
+
+	return ""
+}
+
+func describeCatchUpEn(catchUp string) string {
+	switch catchUp {
+	case model.CatchUpStep:
+		return ", missed occurrences are done one at a time"
+	case model.CatchUpRecord:
+		return ", missed occurrences are recorded"
+	}
+
+	return ""
+}
+
+func describeFromRu(from string) string {
+	if from == model.FromDone {
+		return ", считая от дня выполнения"
+	}
+
+	return ""
+}
+
+func describeFromEn(from string) string {
+	if from == model.FromDone {
+		return ", counted from the completion date"
+	}
+
+	return ""
+}
+
+func describeShiftRu(shift string) string {
+	switch shift {
+	case model.ShiftNext:
+		return ", с переносом на следующий рабочий день"
+	case model.ShiftPrev:
+		return ", с переносом на предыдущий рабочий день"
+	}
+
+	return ""
+}
+
+func describeShiftEn(shift string) string {
+	switch shift {
+	case model.ShiftNext:
+		return ", moved to the next working day"
+	case model.ShiftPrev:
+		return ", moved to the previous working day"
+	}
+
+	return ""
+}
+
+func describeLeapRu(leap string) string {
+	switch leap {
+	case model.LeapFeb28:
+		return ", 29 февраля в невисокосный год переносится на 28 февраля"
+	case model.LeapMar1:
+		return ", 29 февраля в невисокосный год переносится на 1 марта"
+	}
+
+	return ""
+}
+
+func describeLeapEn(leap string) string {
+	switch leap {
+	case model.LeapFeb28:
+		return ", February 29 moves to February 28 in common years"
+	case model.LeapMar1:
+		return ", February 29 moves to March 1 in common years"
+	}
+
+	return ""
+}
+
+func describeRepeatRuleRu(repeatRule model.RepeatRule) string {
+	switch rule := repeatRule.Kind.(type) {
+	case daysRule:
+		if rule.days == 1 {
+			return "ежедневно"
+		}
+
+		return fmt.Sprintf("раз в %d %s", rule.days, pluralRu(rule.days, "день", "дня", "дней"))
+	case workdaysRule:
+		if rule.days == 1 {
+			return "каждый рабочий день"
+		}
+
+		return fmt.Sprintf("раз в %d %s", rule.days, pluralRu(rule.days, "рабочий день", "рабочих дня", "рабочих дней"))
+	case weeksRule:
+		weekdays := make([]string, 0, len(rule.weekdays))
+		for _, wVal := range rule.weekdays {
+			weekdays = append(weekdays, ruWeekdaysDative[wVal-1])
+		}
+		text := "по " + joinWords(weekdays, "и")
+
+		if rule.interval > 1 {
+			text = fmt.Sprintf("раз в %d %s %s", rule.interval, pluralRu(rule.interval, "неделю", "недели", "недель"), text)
+		}
+
+		return text
+	case monthDaysRule:
+		days := make([]string, 0, len(rule.days))
+		for _, dVal := range rule.days {
+			switch dVal {
+			case -1:
+				days = append(days, "последнего")
+			case -2:
+				days = append(days, "предпоследнего")
+			default:
+				days = append(days, strconv.Itoa(dVal)+"-го")
+			}
+		}
+		text := joinWords(days, "и") + " числа"
+
+		if len(rule.months) > 0 {
+			return text + " в " + joinWords(monthNames(rule.months, ruMonthsPrepos), "и")
+		}
+
+		return text + " каждого месяца"
+	case monthsIntervalRule:
+		text := "ежемесячно"
+		if rule.interval > 1 {
+			text = fmt.Sprintf("раз в %d %s", rule.interval, pluralRu(rule.interval, "месяц", "месяца", "месяцев"))
+		}
+
+		switch rule.day {
+		case 0:
+			return text
+		case -1:
+			return text + " в последний день месяца"
+		}
+
+		return text + " " + strconv.Itoa(rule.day) + "-го числа"
+	case monthWeekdaysRule:
+		ordinals := make([]string, 0, len(rule.ordinals))
+		for _, ordinal := range rule.ordinals {
+			gender := ruWeekdayGenders[ordinal.Weekday-1]
+			ordinalWord := ruLastOrdinals[gender]
+			if ordinal.Ordinal != model.LastWeekdayOrdinal {
+				ordinalWord = ruOrdinals[ordinal.Ordinal-1][gender]
+			}
+
+			preposition := "в"
+			if strings.HasPrefix(ordinalWord, "вт") {
+				preposition = "во"
+			}
+			ordinals = append(ordinals, preposition+" "+ordinalWord+" "+ruWeekdays[ordinal.Weekday-1])
+		}
+		text := joinWords(ordinals, "и")
+
+		if len(rule.months) > 0 {
+			return text + " " + joinWords(monthNames(rule.months, ruMonthsGenitive), "и")
+		}
+
+		return text + " месяца"
+	case cronRule:
+		return fmt.Sprintf("по расписанию cron «%s»", strings.Join(rule.fields, " "))
+	case yearsRule:
+		if len(rule.dates) == 0 {
+			return "ежегодно"
+		}
+
+		dates := make([]string, 0, len(rule.dates))
+		for _, yVal := range rule.dates {
+			dates = append(dates, strconv.Itoa(yVal%100)+" "+ruMonthsGenitive[yVal/100-1])
+		}
+
+		if rule.interval > 1 {
+			return fmt.Sprintf("раз в %d %s %s", rule.interval, pluralRu(rule.interval, "год", "года", "лет"), joinWords(dates, "и"))
+		}
+
+		return "ежегодно " + joinWords(dates, "и")
+	case timeIntervalRule:
+		if rule.unit == time.Hour {
+			if rule.interval == 1 {
+				return "каждый час"
+			}
+
+			return fmt.Sprintf("раз в %d %s", rule.interval, pluralRu(rule.interval, "час", "часа", "часов"))
+		}
+		if rule.interval == 1 {
+			return "каждую минуту"
+		}
+
+		return fmt.Sprintf("раз в %d %s", rule.interval, pluralRu(rule.interval, "минуту", "минуты", "минут"))
+	}
+
+	//Виды правил, добавленные через RegisterRepeatKind, описывают себя сами
+	if describer, ok := repeatRule.Kind.(model.RepeatKindDescriber); ok {
+		return describer.Describe(model.LangRu)
+	}
+
+	return ""
+}
+
+func describeRepeatRuleEn(repeatRule model.RepeatRule) string {
+	switch rule := repeatRule.Kind.(type) {
+	case daysRule:
+		if rule.days == 1 {
+			return "every day"
+		}
+
+		return fmt.Sprintf("every %d days", rule.days)
+	case workdaysRule:
+		if rule.days == 1 {
+			return "every working day"
+		}
+
+		return fmt.Sprintf("every %d working days", rule.days)
+	case weeksRule:
+		weekdays := make([]string, 0, len(rule.weekdays))
+		for _, wVal := range rule.weekdays {
+			weekdays = append(weekdays, enWeekdays[wVal-1])
+		}
+
+		if rule.interval > 1 {
+			return fmt.Sprintf("every %d weeks on %s", rule.interval, joinWords(weekdays, "and"))
+		}
+
+		return "every " + joinWords(weekdays, "and")
+	case monthDaysRule:
+		days := make([]string, 0, len(rule.days))
+		for _, dVal := range rule.days {
+			switch dVal {
+			case -1:
+				days = append(days, "last")
+			case -2:
+				days = append(days, "second to last")
+			default:
+				days = append(days, ordinalEn(dVal))
+			}
+		}
+		text := "on the " + joinWords(days, "and") + " day"
+
+		if len(rule.months) > 0 {
+			return text + " of " + joinWords(monthNames(rule.months, enMonths), "and")
+		}
+
+		return text + " of every month"
+	case monthsIntervalRule:
+		text := "every month"
+		if rule.interval > 1 {
+			text = fmt.Sprintf("every %d months", rule.interval)
+		}
+
+		switch rule.day {
+		case 0:
+			return text
+		case -1:
+			return text + " on the last day"
+		}
+
+		return text + " on the " + ordinalEn(rule.day) + " day"
+	case monthWeekdaysRule:
+		ordinals := make([]string, 0, len(rule.ordinals))
+		for _, ordinal := range rule.ordinals {
+			ordinalWord := "last"
+			if ordinal.Ordinal != model.LastWeekdayOrdinal {
+				ordinalWord = ordinalEn(ordinal.Ordinal)
+			}
+			ordinals = append(ordinals, ordinalWord+" "+enWeekdays[ordinal.Weekday-1])
+		}
+		text := "on the " + joinWords(ordinals, "and")
+
+		if len(rule.months) > 0 {
+			return text + " of " + joinWords(monthNames(rule.months, enMonths), "and")
+		}
+
+		return text + " of every month"
+	case cronRule:
+		return fmt.Sprintf("on cron schedule %q", strings.Join(rule.fields, " "))
+	case yearsRule:
+		if len(rule.dates) == 0 {
+			return "every year"
+		}
+
+		dates := make([]string, 0, len(rule.dates))
+		for _, yVal := range rule.dates {
+			dates = append(dates, enMonths[yVal/100-1]+" "+strconv.Itoa(yVal%100))
+		}
+
+		if rule.interval > 1 {
+			return fmt.Sprintf("every %d years on %s", rule.interval, joinWords(dates, "and"))
+		}
+
+		return "every year on " + joinWords(dates, "and")
+	case timeIntervalRule:
+		unit := "minute"
+		if rule.unit == time.Hour {
+			unit = "hour"
+		}
+		if rule.interval == 1 {
+			return "every " + unit
+		}
+
+		return fmt.Sprintf("every %d %ss", rule.interval, unit)
+	}
+
+	//Виды правил, добавленные через RegisterRepeatKind, описывают себя сами
+	if describer, ok := repeatRule.Kind.(model.RepeatKindDescriber); ok {
+		return describer.Describe(model.LangEn)
+	}
+
+	return ""
+}
+
+func monthNames(months []int, names []string) []string {
+	monthNames := make([]string, 0, len(months))
+	for _, month := range months {
+		monthNames = append(monthNames, names[month-1])
+	}
+
+	return monthNames
+}
+
+// joinWords перечисляет слова через запятую, а последнее присоединяет союзом
+func joinWords(words []string, conjunction string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+
+	return strings.Join(words[:len(words)-1], ", ") + " " + conjunction + " " + words[len(words)-1]
+}
+
+func pluralRu(n int, one string, few string, many string) string {
+	if n%100 >= 11 && n%100 <= 14 {
+		return many
+	}
+
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	}
+
+	return many
+}
+
+func ordinalEn(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+
+	return strconv.Itoa(n) + suffix
+}
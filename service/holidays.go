@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go_final_project/database"
+	"go_final_project/service/model"
+	"io"
+	"strings"
+	"time"
+)
+
+// HolidayCalendar источник праздничных и нерабочих дней, которые пропускаются при расчёте рабочих дней
+type HolidayCalendar interface {
+	IsHoliday(date time.Time) (bool, error)
+}
+
+// HolidayDates календарь праздников в памяти, ключ - дата в формате model.CommonDateFormat
+type HolidayDates map[string]bool
+
+func (h HolidayDates) IsHoliday(date time.Time) (bool, error) {
+	return h[date.Format(model.CommonDateFormat)], nil
+}
+
+// GetHolidays получить список праздников
+func (s *Service) GetHolidays(request model.GetHolidaysRequest) ([]model.Holiday, error) {
+	dbHolidays, err := s.storage.GetHolidays(request.Year)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список праздников из базы данных: %s", err.Error())
+	}
+
+	holidays := make([]model.Holiday, 0, len(dbHolidays))
+	for _, holiday := range dbHolidays {
+		holidays = append(holidays, model.Holiday{Date: holiday.Date, Title: holiday.Title})
+	}
+
+	return holidays, nil
+}
+
+// AddHoliday добавить праздник
+func (s *Service) AddHoliday(request model.HolidayRequest) error {
+	err := s.storage.SaveHolidays([]database.Holiday{{Date: request.Date, Title: request.Title}})
+	if err != nil {
+		return fmt.Errorf("ошибка добавления праздника в базу данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// PutHoliday изменить название праздника
+func (s *Service) PutHoliday(request model.HolidayRequest) error {
+	err := s.storage.PutHoliday(database.Holiday{Date: request.Date, Title: request.Title})
+	if err != nil {
+		return fmt.Errorf("ошибка редактирования праздника в базе данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// DeleteHoliday удалить праздник
+func (s *Service) DeleteHoliday(request model.DeleteHolidayRequest) error {
+	err := s.storage.DeleteHoliday(request.Date)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления праздника из базы данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ImportHolidays загрузить праздники из файла в формате CSV или ICS
+func (s *Service) ImportHolidays(request model.ImportHolidaysRequest) (model.ImportHolidaysResponse, error) {
+	var (
+		holidays []database.Holiday
+		err      error
+	)
+
+	switch request.Format {
+	case model.HolidaysFormatCSV:
+		holidays, err = parseHolidaysCSV(request.Content)
+	case model.HolidaysFormatICS:
+		holidays, err = parseHolidaysICS(request.Content)
+	default:
+		err = fmt.Errorf("неизвестный формат файла праздников: %s", request.Format)
+	}
+	if err != nil {
+		return model.ImportHolidaysResponse{}, fmt.Errorf("не удалось разобрать файл праздников: %s", err.Error())
+	}
+
+	if err := s.storage.SaveHolidays(holidays); err != nil {
+		return model.ImportHolidaysResponse{}, fmt.Errorf("ошибка сохранения праздников в базу данных: %s", err.Error())
+	}
+
+	return model.ImportHolidaysResponse{Imported: len(holidays)}, nil
+}
+
+// parseHolidaysCSV разбирает строки вида "дата,название", первая строка может быть заголовком
+func parseHolidaysCSV(content []byte) ([]database.Holiday, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var holidays []database.Holiday
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := parseHolidayDate(record[0])
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("строка %d: %s", line, err.Error())
+		}
+
+		holiday := database.Holiday{Date: date.Format(model.CommonDateFormat)}
+		if len(record) > 1 {
+			holiday.Title = record[1]
+		}
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, nil
+}
+
+// parseHolidaysICS разбирает события VEVENT, многодневное событие даёт праздник на каждый свой день
+func parseHolidaysICS(content []byte) ([]database.Holiday, error) {
+	var (
+		holidays           []database.Holiday
+		inEvent            bool
+		summary            string
+		dateStart, dateEnd time.Time
+	)
+
+	for _, line := range unfoldICSLines(content) {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		//Параметры свойства вроде DTSTART;VALUE=DATE нам не нужны
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			summary, dateStart, dateEnd = "", time.Time{}, time.Time{}
+		case name == "END" && value == "VEVENT":
+			if dateStart.IsZero() {
+				return nil, fmt.Errorf("у события %q не указана дата DTSTART", summary)
+			}
+			if dateEnd.IsZero() || !dateEnd.After(dateStart) {
+				dateEnd = dateStart.AddDate(0, 0, 1)
+			}
+			//Событие разворачивается в отдельный праздник на каждый день, поэтому длина события ограничена
+			if dateEnd.After(dateStart.AddDate(0, 0, model.MaxHolidayDays)) {
+				return nil, fmt.Errorf("событие %q длиннее %d дней", summary, model.MaxHolidayDays)
+			}
+			for date := dateStart; date.Before(dateEnd); date = date.AddDate(0, 0, 1) {
+				holidays = append(holidays, database.Holiday{Date: date.Format(model.CommonDateFormat), Title: summary})
+			}
+			inEvent = false
+		case inEvent && name == "SUMMARY":
+			summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\\`, `\`).Replace(value)
+		case inEvent && (name == "DTSTART" || name == "DTEND"):
+			if len(value) < len(model.CommonDateFormat) {
+				return nil, fmt.Errorf("некорректная дата %s: %s", name, value)
+			}
+			date, err := DateParse(value[:len(model.CommonDateFormat)])
+			if err != nil {
+				return nil, fmt.Errorf("некорректная дата %s: %s", name, err.Error())
+			}
+			if name == "DTSTART" {
+				dateStart = date
+			} else {
+				dateEnd = date
+			}
+		}
+	}
+
+	return holidays, nil
+}
+
+// unfoldICSLines склеивает строки ICS, перенесённые по RFC 5545 (продолжение начинается с пробела или табуляции)
+func unfoldICSLines(content []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// parseHolidayDate разбирает дату праздника в форматах 20060102, 02.01.2006 или 2006-01-02
+func parseHolidayDate(dateStr string) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
+	for _, layout := range []string{model.CommonDateFormat, model.SearchDateFormat, time.DateOnly} {
+		if date, err := time.Parse(layout, dateStr); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("некорректная дата праздника: %s", dateStr)
+}
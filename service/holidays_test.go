@@ -0,0 +1,35 @@
+package service_test
+
+import (
+	"go_final_project/config"
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHolidayCalendar(t *testing.T) {
+	svc := service.NewService(nil, service.HolidayDates{"20240129": true, "20240130": true},
+		&config.Config{Workdays: []int{1, 2, 3, 4, 5}})
+
+	date, err := time.Parse(model.CommonDateFormat, "20240126")
+	assert.NoError(t, err)
+	for repeat, want := range map[string]string{
+		"bd 1":              "20240131",
+		"bd 2":              "20240201",
+		"m 28 shift=next":   "20240131",
+		"m 29 shift=prev":   "20240229",
+		"d 3 shift=prev":    "20240201",
+		"w 1 shift=next":    "20240131",
+		"mw 5:1 shift=prev": "20240429",
+	} {
+		repeatRule, err := service.PrepareRepeatRuleFromRawString(repeat)
+		assert.NoError(t, err)
+
+		next, err := svc.CalculateNextDate(model.NextDateRequest{Now: date, Date: date, Repeat: repeatRule})
+		assert.NoError(t, err)
+		assert.Equal(t, want, next.Format(model.CommonDateFormat), repeat)
+	}
+}
@@ -1,7 +1,57 @@
 package model
 
+import "time"
+
 const (
 	CommonDateFormat = "20060102"
 	SearchDateFormat = "02.01.2006"
+	TimeFormat       = "15:04"
+	DateTimeFormat   = CommonDateFormat + " " + TimeFormat
 	LimitTasks       = 10
+	LimitCompletions = 50
+
+	TrashPurgeInterval = time.Hour
+
+	DefaultNextDatesCount = 10
+	MaxNextDatesCount     = 100
+
+	LastWeekdayOrdinal    = -1
+	LastWeekdayOrdinalRaw = "last"
+	MaxSearchDays         = 366 * 30
+
+	RRulePrefix = "RRULE:"
+
+	ShiftModifier = "shift"
+	ShiftNext     = "next"
+	ShiftPrev     = "prev"
+
+	FromModifier = "from"
+	FromDate     = "date"
+	FromDone     = "done"
+
+	CatchUpModifier = "catchup"
+	CatchUpSkip     = "skip"
+	CatchUpStep     = "step"
+	CatchUpRecord   = "record"
+	MaxMissedCount  = 100
+
+	LeapModifier       = "leap"
+	LeapFeb28          = "feb28"
+	LeapMar1           = "mar1"
+	MaxYearsInterval   = 100
+	MaxMonthsInterval  = 120
+	MaxHoursInterval   = 24 * 7
+	MaxMinutesInterval = 24 * 60
+
+	HolidaysFormatCSV = "csv"
+	HolidaysFormatICS = "ics"
+	MaxHolidayDays    = 366
+
+	LangRu = "ru"
+	LangEn = "en"
+
+	// TimezoneHeader заголовок, которым клиент передаёт свой часовой пояс, например Europe/Moscow
+	TimezoneHeader = "X-Timezone"
+	// DebugNowHeader заголовок, которым авторизованный клиент подменяет текущий момент в формате DateTimeFormat или CommonDateFormat
+	DebugNowHeader = "X-Debug-Now"
 )
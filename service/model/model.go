@@ -6,20 +6,89 @@ type NextDateRequest struct {
 	Now    time.Time
 	Date   time.Time
 	Repeat RepeatRule
+	// Exceptions даты в формате CommonDateFormat, которые пропускаются при повторении задачи
+	Exceptions []string
+}
+
+type NextDatesRequest struct {
+	NextDateRequest
+	Count int
+	Until time.Time
+}
+
+type NextDatesResponse struct {
+	Dates []string `json:"dates"`
+}
+
+type NextDatesResponseWithError struct {
+	Error string `json:"error"`
 }
 
 type RepeatRule struct {
-	Name   string
-	Value  *int
-	Values [][]int
+	Name string
+	// Kind значения правила, конкретный тип зависит от вида правила Name
+	Kind RepeatKind
+	// Shift перенос даты, выпавшей на выходной, на ближайший рабочий день: ShiftNext или ShiftPrev
+	Shift string
+	// Leap на какую дату переносится 29 февраля в невисокосный год: LeapFeb28 или LeapMar1
+	Leap string
+	// From от какой даты отсчитывается следующее повторение при выполнении задачи: FromDate (по умолчанию) или FromDone
+	From string
+	// CatchUp что делать с пропущенными повторениями при выполнении просроченной задачи:
+	// CatchUpSkip (по умолчанию), CatchUpStep или CatchUpRecord
+	CatchUp string
+}
+
+// RepeatKind значения правила повторения определённого вида. Каждый вид сам проверяет свои значения,
+// вычисляет следующую дату и собирает каноническую строку правила без модификаторов
+type RepeatKind interface {
+	Validate() error
+	Next(calendar WorkCalendar, request NextDateRequest) (time.Time, error)
+	String() string
+}
+
+// RepeatKindDescriber вид правила повторения, который сам описывает себя человеческим языком lang (LangRu или LangEn).
+// Встроенные виды описываются в service/describe.go, виды из service.RegisterRepeatKind - этим методом
+type RepeatKindDescriber interface {
+	Describe(lang string) string
+}
+
+// RepeatKindRRuleConverter вид правила повторения, который сам переводит себя в части RRULE вроде "FREQ=DAILY".
+// Встроенные виды переводятся в service/rrule.go, виды из service.RegisterRepeatKind - этим методом
+type RepeatKindRRuleConverter interface {
+	RRuleParts() ([]string, error)
+}
+
+// WorkCalendar настройки планировщика, которые нужны правилам повторения для расчёта дат
+type WorkCalendar interface {
+	// IsWorkday проверяет, что дата попадает на рабочий день недели и не является праздником
+	IsWorkday(date time.Time) (bool, error)
+	// LeapDay на какую дату по умолчанию переносится 29 февраля в невисокосный год
+	LeapDay() string
+}
+
+// WeekdayOrdinal день недели с порядковым номером в месяце для правила mw (например, вторая среда)
+type WeekdayOrdinal struct {
+	// Ordinal порядковый номер дня недели в месяце от 1 до 5, LastWeekdayOrdinal - последний
+	Ordinal int
+	Weekday int
 }
 
 type AddTaskRequest struct {
-	Date      string `json:"date"`
-	Title     string `json:"title"`
-	Comment   string `json:"comment"`
-	RepeatRaw string `json:"repeat"`
-	Repeat    RepeatRule
+	Date string `json:"date"`
+	// Time время выполнения задачи в формате TimeFormat, пустое - в течение дня
+	Time        string `json:"time"`
+	Title       string `json:"title"`
+	Comment     string `json:"comment"`
+	RepeatRaw   string `json:"repeat"`
+	RepeatUntil string `json:"repeat_until"`
+	RepeatCount int    `json:"repeat_count"`
+	Repeat      RepeatRule
+	Exceptions  []string `json:"exceptions"`
+	// Timezone часовой пояс запроса, если не передан - используется часовой пояс планировщика
+	Timezone *time.Location `json:"-"`
+	// DebugNow подменённый текущий момент по часам часового пояса запроса, нулевой - текущий момент по часам сервиса
+	DebugNow time.Time `json:"-"`
 }
 
 type AddTaskResponse struct {
@@ -28,16 +97,24 @@ type AddTaskResponse struct {
 }
 
 type Task struct {
-	Id      string `json:"id"`
-	Date    string `json:"date"`
-	Title   string `json:"title"`
-	Comment string `json:"comment"`
-	Repeat  string `json:"repeat"`
+	Id          string   `json:"id"`
+	Date        string   `json:"date"`
+	Time        string   `json:"time,omitempty"`
+	Title       string   `json:"title"`
+	Comment     string   `json:"comment"`
+	Repeat      string   `json:"repeat"`
+	RepeatUntil string   `json:"repeat_until,omitempty"`
+	RepeatCount int      `json:"repeat_count,omitempty"`
+	RepeatText  string   `json:"repeat_text,omitempty"`
+	Exceptions  []string `json:"exceptions,omitempty"`
+	Missed      []string `json:"missed,omitempty"`
+	DeletedAt   string   `json:"deleted_at,omitempty"`
 }
 
 type ClosestTasksRequest struct {
 	SearchDate  time.Time
 	SearchTitle string
+	Lang        string
 }
 
 type ClosestTasksResponse struct {
@@ -50,6 +127,7 @@ type ClosestTasksResponseWithError struct {
 
 type GetTaskRequest struct {
 	TaskId string `json:"id"`
+	Lang   string
 }
 
 type GetTaskResponse struct {
@@ -63,6 +141,12 @@ type GetTaskResponseWithError struct {
 type PutTaskRequest struct {
 	Task
 	RepeatRule RepeatRule
+	Timezone   *time.Location `json:"-"`
+	DebugNow   time.Time      `json:"-"`
+	// HasTime, HasRepeatUntil и HasRepeatCount - поля переданы в запросе, отсутствующие поля сохраняют прежние значения задачи
+	HasTime        bool `json:"-"`
+	HasRepeatUntil bool `json:"-"`
+	HasRepeatCount bool `json:"-"`
 }
 
 type PutTaskResponse struct{}
@@ -72,15 +156,173 @@ type PutTaskResponseWithError struct {
 }
 
 type DoTaskRequest struct {
-	TaskId string `json:"id"`
+	TaskId   string         `json:"id"`
+	Timezone *time.Location `json:"-"`
+	DebugNow time.Time      `json:"-"`
+	// CatchUp политика для пропущенных повторений на этот раз, пустая - политика из правила повторения задачи
+	CatchUp string `json:"-"`
 }
 
-type DoTaskResponse struct{}
+// DoTaskResponse итог выполнения повторяющейся задачи, для удалённой задачи все поля пустые
+type DoTaskResponse struct {
+	Date    string `json:"date,omitempty"`
+	Time    string `json:"time,omitempty"`
+	CatchUp string `json:"catchup,omitempty"`
+	// Missed пропущенные повторения в формате CommonDateFormat или DateTimeFormat, записанные при CatchUpRecord
+	Missed []string `json:"missed,omitempty"`
+}
 
 type DoTaskResponseWithError struct {
 	Error string `json:"error"`
 }
 
+type TrashRequest struct {
+	Lang string
+}
+
+type RestoreTaskRequest struct {
+	TaskId string `json:"id"`
+}
+
+type RestoreTaskResponse struct{}
+
+type RestoreTaskResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type TaskHistoryRequest struct {
+	TaskId string `json:"id"`
+}
+
+// Completion выполнение задачи: на когда она была запланирована, когда выполнена и на когда перенесена,
+// NextDate пустая, если после выполнения задача удалена
+type Completion struct {
+	Id       string `json:"id"`
+	TaskId   string `json:"task_id"`
+	Title    string `json:"title"`
+	Date     string `json:"date"`
+	Time     string `json:"time,omitempty"`
+	DoneAt   string `json:"done_at"`
+	NextDate string `json:"next_date,omitempty"`
+	NextTime string `json:"next_time,omitempty"`
+}
+
+type TaskHistoryResponse struct {
+	History []Completion `json:"history"`
+}
+
+type TaskHistoryResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type DatePreviewRequest struct {
+	// Phrase дата в формате CommonDateFormat, ISO 8601 или фразой вроде "завтра", "через 3 дня", "next monday"
+	Phrase   string
+	Timezone *time.Location
+	DebugNow time.Time
+}
+
+type DatePreviewResponse struct {
+	Date string `json:"date"`
+}
+
+type DatePreviewResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type ParseRepeatRequest struct {
+	RepeatRaw string
+	Repeat    RepeatRule
+}
+
+type ParseRepeatResponse struct {
+	Repeat string `json:"repeat"`
+}
+
+type ParseRepeatResponseWithError struct {
+	Error string `json:"error"`
+}
+
+// StatusResponse какое хранилище выбрано строкой подключения и каким драйвером оно открыто
+type StatusResponse struct {
+	Storage string `json:"storage"`
+	Driver  string `json:"driver,omitempty"`
+}
+
+type RRuleRequest struct {
+	RepeatRaw string
+	Repeat    RepeatRule
+}
+
+type RRuleResponse struct {
+	RRule string `json:"rrule"`
+}
+
+type RRuleResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type DescribeRepeatRequest struct {
+	RepeatRaw string
+	Repeat    RepeatRule
+	Lang      string
+}
+
+type DescribeRepeatResponse struct {
+	Text string `json:"text"`
+}
+
+type DescribeRepeatResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type Holiday struct {
+	Date  string `json:"date"`
+	Title string `json:"title"`
+}
+
+type GetHolidaysRequest struct {
+	Year int
+}
+
+type GetHolidaysResponse struct {
+	Holidays []Holiday `json:"holidays"`
+}
+
+type HolidayRequest struct {
+	Holiday
+}
+
+type DeleteHolidayRequest struct {
+	Date string
+}
+
+type HolidayResponse struct{}
+
+type HolidayResponseWithError struct {
+	Error string `json:"error"`
+}
+
+type ImportHolidaysRequest struct {
+	Format  string
+	Content []byte
+}
+
+type ImportHolidaysResponse struct {
+	Imported int `json:"imported"`
+}
+
+type TaskExceptionRequest struct {
+	TaskId string `json:"id"`
+	Date   string `json:"date"`
+}
+
+type TaskExceptionResponse struct{}
+
+type TaskExceptionResponseWithError struct {
+	Error string `json:"error"`
+}
+
 type SingInRequest struct {
 	Password string `json:"password"`
 }
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"go_final_project/service/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoDateFormat дата в формате ISO 8601
+const isoDateFormat = "2006-01-02"
+
+// relativeDays фразы, которые сдвигают сегодняшнюю дату на фиксированное число дней
+var relativeDays = map[string]int{
+	"вчера":              -1,
+	"сегодня":            0,
+	"завтра":             1,
+	"послезавтра":        2,
+	"yesterday":          -1,
+	"today":              0,
+	"tomorrow":           1,
+	"day after tomorrow": 2,
+}
+
+// dateUnits единицы интервала во фразах "через 3 дня" и "in 2 weeks": сколько дней или месяцев в единице
+var dateUnits = map[string]struct{ days, months int }{
+	"день": {days: 1}, "дня": {days: 1}, "дней": {days: 1},
+	"неделю": {days: 7}, "недели": {days: 7}, "недель": {days: 7},
+	"месяц": {months: 1}, "месяца": {months: 1}, "месяцев": {months: 1},
+	"год": {months: 12}, "года": {months: 12}, "лет": {months: 12},
+	"day": {days: 1}, "days": {days: 1},
+	"week": {days: 7}, "weeks": {days: 7},
+	"month": {months: 1}, "months": {months: 1},
+	"year": {months: 12}, "years": {months: 12},
+}
+
+// singleDateUnits единицы интервала, которые пишут без числа: "через неделю", "in a month"
+var singleDateUnits = map[string]bool{
+	"день": true, "неделю": true, "месяц": true, "год": true,
+	"day": true, "week": true, "month": true, "year": true,
+}
+
+// weekdayNames названия дней недели в формах, в которых их пишут в фразах "в пятницу" и "next monday"
+var weekdayNames = map[string]int{
+	"понедельник": 1, "пн": 1, "monday": 1, "mon": 1,
+	"вторник": 2, "вт": 2, "tuesday": 2, "tue": 2,
+	"среду": 3, "среда": 3, "ср": 3, "wednesday": 3, "wed": 3,
+	"четверг": 4, "чт": 4, "thursday": 4, "thu": 4,
+	"пятницу": 5, "пятница": 5, "пт": 5, "friday": 5, "fri": 5,
+	"субботу": 6, "суббота": 6, "сб": 6, "saturday": 6, "sat": 6,
+	"воскресенье": 7, "вс": 7, "sunday": 7, "sun": 7,
+}
+
+// weekdayPrefixes слова перед днём недели, которые не меняют смысл фразы
+var weekdayPrefixes = map[string]bool{
+	"в": true, "во": true, "on": true,
+	"следующий": true, "следующую": true, "следующее": true, "next": true,
+}
+
+// ParseNaturalDate переводит в дату фразу вроде "завтра", "через 3 дня", "в пятницу", "next monday",
+// а также дату в формате CommonDateFormat или ISO 8601. Относительные фразы считаются от today
+func ParseNaturalDate(phrase string, today time.Time) (time.Time, error) {
+	today = startOfDay(today)
+	normalized := strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(phrase), "ё", "е")), " ")
+
+	for _, layout := range []string{model.CommonDateFormat, isoDateFormat} {
+		if date, err := time.Parse(layout, normalized); err == nil {
+			return date, nil
+		}
+	}
+
+	if days, ok := relativeDays[normalized]; ok {
+		return today.AddDate(0, 0, days), nil
+	}
+
+	words := strings.Fields(normalized)
+	if len(words) >= 2 && (words[0] == "через" || words[0] == "in") {
+		if date, ok := parseRelativeDate(words[1:], today); ok {
+			return date, nil
+		}
+	}
+
+	for len(words) > 1 && weekdayPrefixes[words[0]] {
+		words = words[1:]
+	}
+	if len(words) == 1 {
+		if weekday, ok := weekdayNames[words[0]]; ok {
+			//Ближайший такой день недели после сегодняшнего, сегодняшний день не подходит
+			days := (weekday-weekdayNumber(today)+6)%7 + 1
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("не удалось распознать дату %s", phrase)
+}
+
+// parseRelativeDate разбирает интервал из фраз "через 3 дня", "через неделю", "in a month"
+func parseRelativeDate(words []string, today time.Time) (time.Time, bool) {
+	count := 1
+	switch {
+	case len(words) == 1 && singleDateUnits[words[0]]:
+	case len(words) == 2 && (words[0] == "a" || words[0] == "an") && singleDateUnits[words[1]]:
+		words = words[1:]
+	case len(words) == 2:
+		var err error
+		count, err = strconv.Atoi(words[0])
+		if err != nil || count < 0 {
+			return time.Time{}, false
+		}
+		words = words[1:]
+	default:
+		return time.Time{}, false
+	}
+
+	unit, ok := dateUnits[words[0]]
+	if !ok {
+		return time.Time{}, false
+	}
+	if unit.months > 0 {
+		//31 января через месяц - последний день февраля, а не начало марта
+		return monthDate(today.Year(), today.Month()+time.Month(count*unit.months), today.Day()), true
+	}
+
+	return today.AddDate(0, 0, count*unit.days), true
+}
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNaturalDate(t *testing.T) {
+	// 20240126 - пятница
+	tbl := []struct {
+		today  string
+		phrase string
+		want   string
+	}{
+		{"20240126", "сегодня", "20240126"},
+		{"20240126", "Завтра", "20240127"},
+		{"20240126", "послезавтра", "20240128"},
+		{"20240126", "вчера", "20240125"},
+		{"20240126", "через 3 дня", "20240129"},
+		{"20240126", "через  1 день", "20240127"},
+		{"20240126", "через неделю", "20240202"},
+		{"20240126", "через 2 недели", "20240209"},
+		{"20240126", "через месяц", "20240226"},
+		{"20240131", "через месяц", "20240229"},
+		{"20240229", "через год", "20250228"},
+		{"20240126", "in 2 weeks", "20240209"},
+		{"20240126", "in a month", "20240226"},
+		{"20240126", "tomorrow", "20240127"},
+		{"20240126", "в пятницу", "20240202"},
+		{"20240126", "во вторник", "20240130"},
+		{"20240126", "в следующий понедельник", "20240129"},
+		{"20240126", "в субботу", "20240127"},
+		{"20240126", "next monday", "20240129"},
+		{"20240126", "Sunday", "20240128"},
+		{"20240126", "2026-10-19", "20261019"},
+		{"20240126", "20261019", "20261019"},
+		{"20240126", "когда-нибудь", ""},
+		{"20240126", "через дня", ""},
+		{"20240126", "через -1 день", ""},
+		{"20240126", "28.01.2024", ""},
+		{"20240126", "20240192", ""},
+		{"20240126", "next", ""},
+	}
+	for _, v := range tbl {
+		today, err := time.Parse(model.CommonDateFormat, v.today)
+		assert.NoError(t, err)
+
+		date, err := service.ParseNaturalDate(v.phrase, today)
+		if v.want == "" {
+			assert.Error(t, err, "фраза %q", v.phrase)
+			continue
+		}
+		if assert.NoError(t, err, "фраза %q", v.phrase) {
+			assert.Equal(t, v.want, date.Format(model.CommonDateFormat), "фраза %q от %s", v.phrase, v.today)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+)
+
+// repeatUnit единица интервала во фразах вроде "каждые 3 дня" и "every 2 weeks"
+type repeatUnit int
+
+const (
+	dayRepeatUnit repeatUnit = iota + 1
+	weekRepeatUnit
+	monthRepeatUnit
+	yearRepeatUnit
+	hourRepeatUnit
+	minuteRepeatUnit
+)
+
+// repeatUnits формы слов, обозначающих единицу интервала, наречия вроде "ежедневно" означают интервал 1
+var repeatUnits = map[string]repeatUnit{
+	"день": dayRepeatUnit, "дня": dayRepeatUnit, "дней": dayRepeatUnit, "ежедневно": dayRepeatUnit,
+	"day": dayRepeatUnit, "days": dayRepeatUnit, "daily": dayRepeatUnit,
+	"неделя": weekRepeatUnit, "неделю": weekRepeatUnit, "недели": weekRepeatUnit, "недель": weekRepeatUnit, "еженедельно": weekRepeatUnit,
+	"week": weekRepeatUnit, "weeks": weekRepeatUnit, "weekly": weekRepeatUnit,
+	"месяц": monthRepeatUnit, "месяца": monthRepeatUnit, "месяцев": monthRepeatUnit, "ежемесячно": monthRepeatUnit,
+	"month": monthRepeatUnit, "months": monthRepeatUnit, "monthly": monthRepeatUnit,
+	"год": yearRepeatUnit, "года": yearRepeatUnit, "лет": yearRepeatUnit, "ежегодно": yearRepeatUnit,
+	"year": yearRepeatUnit, "years": yearRepeatUnit, "yearly": yearRepeatUnit, "annually": yearRepeatUnit,
+	"час": hourRepeatUnit, "часа": hourRepeatUnit, "часов": hourRepeatUnit, "ежечасно": hourRepeatUnit,
+	"hour": hourRepeatUnit, "hours": hourRepeatUnit, "hourly": hourRepeatUnit,
+	"минута": minuteRepeatUnit, "минуту": minuteRepeatUnit, "минуты": minuteRepeatUnit, "минут": minuteRepeatUnit,
+	"minute": minuteRepeatUnit, "minutes": minuteRepeatUnit,
+}
+
+// repeatWeekdayPluralNames дни недели во фразах "по понедельникам" и "on mondays", остальные формы берутся из weekdayNames
+var repeatWeekdayPluralNames = map[string]int{
+	"понедельникам": 1, "mondays": 1,
+	"вторникам": 2, "tuesdays": 2,
+	"средам": 3, "wednesdays": 3,
+	"четвергам": 4, "thursdays": 4,
+	"пятницам": 5, "fridays": 5,
+	"субботам": 6, "saturdays": 6,
+	"воскресеньям": 7, "sundays": 7,
+}
+
+// repeatWeekdayGroups слова, которые обозначают сразу несколько дней недели
+var repeatWeekdayGroups = map[string][]int{
+	"будни": {1, 2, 3, 4, 5}, "будням": {1, 2, 3, 4, 5}, "будний": {1, 2, 3, 4, 5},
+	"weekday": {1, 2, 3, 4, 5}, "weekdays": {1, 2, 3, 4, 5},
+	"выходные": {6, 7}, "выходным": {6, 7}, "weekend": {6, 7}, "weekends": {6, 7},
+}
+
+// repeatMonthDays слова, которые обозначают день месяца с конца
+var repeatMonthDays = map[string]int{
+	"последний": -1, "последнее": -1, "последнего": -1, "last": -1,
+	"предпоследний": -2, "предпоследнее": -2, "предпоследнего": -2,
+}
+
+// repeatMonthDayMarkers слова, после которых числа во фразе считаются днями месяца: "каждое 1 и 15 число"
+var repeatMonthDayMarkers = map[string]bool{
+	"число": true, "числа": true, "числам": true,
+}
+
+// repeatFillers слова, которые не меняют смысл фразы
+var repeatFillers = map[string]bool{
+	"каждый": true, "каждую": true, "каждое": true, "каждые": true, "каждого": true, "раз": true,
+	"по": true, "в": true, "во": true, "и": true,
+	"every": true, "each": true, "once": true, "on": true, "and": true, "the": true, "of": true, "a": true,
+}
+
+// repeatOrdinalSuffixes окончания порядковых числительных: "15-е", "1st", "22nd", при них число - день месяца
+var repeatOrdinalSuffixes = []string{"-е", "-го", "st", "nd", "rd", "th"}
+
+// naturalRepeatPhrase значимые части фразы повторения
+type naturalRepeatPhrase struct {
+	numbers   []int
+	weekdays  []int
+	monthDays []int
+	units     map[repeatUnit]bool
+	monthDay  bool
+	//monthInterval число прямо перед словом "месяц": "каждые 3 месяца 10 числа", его нельзя путать с днём месяца
+	monthInterval int
+}
+
+// ConvertNaturalRepeat переводит фразу вроде "каждый понедельник и четверг", "каждые 3 дня",
+// "каждое 1 и 15 число", "every last day of month" в правило повторения во внутреннем формате
+func ConvertNaturalRepeat(phrase string) (string, bool) {
+	normalized := strings.NewReplacer("ё", "е", ",", " ").Replace(strings.ToLower(phrase))
+	words := strings.Fields(normalized)
+	if len(words) == 0 {
+		return "", false
+	}
+	if _, ok := repeatKinds[words[0]]; ok {
+		return "", false
+	}
+
+	parts, ok := parseNaturalRepeatWords(words)
+	if !ok {
+		return "", false
+	}
+
+	//Дни недели: "по понедельникам", "каждый будний день", "каждые 2 недели в пятницу"
+	if len(parts.weekdays) > 0 {
+		delete(parts.units, dayRepeatUnit)
+		if len(parts.monthDays) > 0 || parts.monthDay || len(parts.units) > 1 || (len(parts.units) == 1 && !parts.units[weekRepeatUnit]) {
+			return "", false
+		}
+		rule := "w " + joinInts(parts.weekdays)
+		switch {
+		case len(parts.numbers) == 1 && parts.units[weekRepeatUnit]:
+			rule += " " + strconv.Itoa(parts.numbers[0])
+		case len(parts.numbers) > 0:
+			return "", false
+		}
+
+		return rule, true
+	}
+
+	//Дни месяца: "каждое 1 и 15 число", "в последний день месяца", "every 1st and 15th"
+	if parts.monthDay || len(parts.monthDays) > 0 || (len(parts.numbers) > 0 && parts.units[dayRepeatUnit] && parts.units[monthRepeatUnit]) {
+		days := append(parts.numbers, parts.monthDays...)
+		delete(parts.units, dayRepeatUnit)
+		delete(parts.units, monthRepeatUnit)
+		if len(days) == 0 || len(parts.units) > 0 {
+			return "", false
+		}
+		if parts.monthInterval > 0 {
+			if len(days) > 1 {
+				return "", false
+			}
+			return "mi " + strconv.Itoa(parts.monthInterval) + " " + strconv.Itoa(days[0]), true
+		}
+
+		return "m " + joinInts(days), true
+	}
+
+	//Интервал: "каждые 3 дня", "ежемесячно", "every 2 hours"
+	if len(parts.units) != 1 || len(parts.numbers) > 1 {
+		return "", false
+	}
+	interval := 1
+	if len(parts.numbers) == 1 {
+		interval = parts.numbers[0]
+	}
+	if parts.monthInterval > 0 {
+		if len(parts.numbers) > 0 {
+			return "", false
+		}
+		interval = parts.monthInterval
+	}
+	for unit := range parts.units {
+		switch unit {
+		case dayRepeatUnit:
+			return "d " + strconv.Itoa(interval), true
+		case weekRepeatUnit:
+			return "d " + strconv.Itoa(interval*7), true
+		case monthRepeatUnit:
+			return "mi " + strconv.Itoa(interval), true
+		case yearRepeatUnit:
+			if interval == 1 {
+				return "y", true
+			}
+			return "mi " + strconv.Itoa(interval*12), true
+		case hourRepeatUnit:
+			return "h " + strconv.Itoa(interval), true
+		case minuteRepeatUnit:
+			return "min " + strconv.Itoa(interval), true
+		}
+	}
+
+	return "", false
+}
+
+// parseNaturalRepeatWords раскладывает слова фразы по значению, незнакомое слово делает фразу нераспознанной
+func parseNaturalRepeatWords(words []string) (naturalRepeatPhrase, bool) {
+	parts := naturalRepeatPhrase{units: make(map[repeatUnit]bool)}
+	//afterNumber предыдущее слово - обычное, не порядковое число
+	afterNumber := false
+	for _, word := range words {
+		wasAfterNumber := afterNumber
+		afterNumber = false
+		if repeatFillers[word] {
+			continue
+		}
+		if repeatMonthDayMarkers[word] {
+			parts.monthDay = true
+			continue
+		}
+		if unit, ok := repeatUnits[word]; ok {
+			parts.units[unit] = true
+			if unit == monthRepeatUnit && wasAfterNumber && parts.monthInterval == 0 {
+				parts.monthInterval = parts.numbers[len(parts.numbers)-1]
+				parts.numbers = parts.numbers[:len(parts.numbers)-1]
+			}
+			continue
+		}
+		if weekday, ok := weekdayNames[word]; ok {
+			parts.weekdays = append(parts.weekdays, weekday)
+			continue
+		}
+		if weekday, ok := repeatWeekdayPluralNames[word]; ok {
+			parts.weekdays = append(parts.weekdays, weekday)
+			continue
+		}
+		if weekdays, ok := repeatWeekdayGroups[word]; ok {
+			parts.weekdays = append(parts.weekdays, weekdays...)
+			continue
+		}
+		if day, ok := repeatMonthDays[word]; ok {
+			parts.monthDays = append(parts.monthDays, day)
+			continue
+		}
+
+		ordinal := false
+		for _, suffix := range repeatOrdinalSuffixes {
+			if numberStr, found := strings.CutSuffix(word, suffix); found && numberStr != "" {
+				word = numberStr
+				parts.monthDay = true
+				ordinal = true
+				break
+			}
+		}
+		number, err := strconv.Atoi(word)
+		if err != nil || number < 1 {
+			return naturalRepeatPhrase{}, false
+		}
+		parts.numbers = append(parts.numbers, number)
+		afterNumber = !ordinal
+	}
+
+	return parts, true
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_final_project/service/model"
+	"strings"
+	"time"
+)
+
+// RepeatKindParser разбирает группы значений, переданные в правиле повторения после его имени
+type RepeatKindParser func(values []string) (model.RepeatKind, error)
+
+// repeatKinds реестр видов правил повторения: имя правила и разбор его значений
+var repeatKinds = map[string]RepeatKindParser{
+	"d":    parseDaysRule,
+	"bd":   parseWorkdaysRule,
+	"w":    parseWeeksRule,
+	"m":    parseMonthDaysRule,
+	"mi":   parseMonthsIntervalRule,
+	"mw":   parseMonthWeekdaysRule,
+	"cron": parseCronRule,
+	"y":    parseYearsRule,
+	"h":    parseHoursRule,
+	"min":  parseMinutesRule,
+}
+
+// RegisterRepeatKind добавляет в реестр новый вид правила повторения или заменяет существующий. Описание
+// и перевод в RRULE новый вид получает, только если реализует model.RepeatKindDescriber и model.RepeatKindRRuleConverter
+func RegisterRepeatKind(name string, parse RepeatKindParser) {
+	repeatKinds[name] = parse
+}
+
+func PrepareRepeatRuleFromRawString(repeatRuleRaw string) (model.RepeatRule, error) {
+	repeatRule := model.RepeatRule{}
+	if repeatRuleRaw == "" {
+		return repeatRule, nil
+	}
+
+	if IsRRule(repeatRuleRaw) {
+		return prepareRepeatRuleFromRRule(repeatRuleRaw)
+	}
+
+	if naturalRepeatRaw, ok := ConvertNaturalRepeat(repeatRuleRaw); ok {
+		return PrepareRepeatRuleFromRawString(naturalRepeatRaw)
+	}
+
+	repeatSlice, err := prepareRepeatModifiers(&repeatRule, strings.Fields(repeatRuleRaw))
+	if err != nil {
+		return repeatRule, err
+	}
+	if len(repeatSlice) == 0 {
+		return repeatRule, errors.New("формат правила повторения не соблюден")
+	}
+
+	repeatRule.Name = repeatSlice[0]
+	parse, ok := repeatKinds[repeatRule.Name]
+	if !ok {
+		return repeatRule, fmt.Errorf("неизвестное правило повторения: %s", repeatRule.Name)
+	}
+
+	repeatRule.Kind, err = parse(repeatSlice[1:])
+	if err != nil {
+		return repeatRule, fmt.Errorf("формат правила повторения для %s не соблюден: %s", strings.ToUpper(repeatRule.Name), err.Error())
+	}
+
+	return repeatRule, nil
+}
+
+// prepareRepeatModifiers забирает из правила модификаторы вида ключ=значение и возвращает остальные группы значений
+func prepareRepeatModifiers(repeatRule *model.RepeatRule, repeatSlice []string) ([]string, error) {
+	groups := make([]string, 0, len(repeatSlice))
+	for i, group := range repeatSlice {
+		key, value, found := strings.Cut(group, "=")
+		if i == 0 || !found {
+			groups = append(groups, group)
+			continue
+		}
+
+		switch key {
+		case model.ShiftModifier:
+			repeatRule.Shift = value
+		case model.LeapModifier:
+			repeatRule.Leap = value
+		case model.FromModifier:
+			repeatRule.From = value
+		case model.CatchUpModifier:
+			repeatRule.CatchUp = value
+		default:
+			return nil, fmt.Errorf("неизвестный модификатор правила повторения: %s", key)
+		}
+	}
+
+	return groups, nil
+}
+
+// anchorRepeatRule дописывает в правило день исходной даты задачи, который иначе теряется,
+// как только короткий месяц или невисокосный год сдвинет дату задачи: mi без дня получает день даты,
+// y на 29 февраля - дату 0229
+func anchorRepeatRule(repeatRule model.RepeatRule, date time.Time) model.RepeatRule {
+	switch kind := repeatRule.Kind.(type) {
+	case monthsIntervalRule:
+		if kind.day == 0 {
+			kind.day = date.Day()
+			repeatRule.Kind = kind
+		}
+	case yearsRule:
+		if len(kind.dates) == 0 && date.Month() == time.February && date.Day() == 29 {
+			kind.dates = []int{229}
+			repeatRule.Kind = kind
+		}
+	}
+
+	return repeatRule
+}
+
+// FormatRepeatRule собирает каноническую строку правила повторения в том виде, в котором она хранится в колонке repeat
+func FormatRepeatRule(repeatRule model.RepeatRule) string {
+	if repeatRule.Kind == nil {
+		return ""
+	}
+
+	groups := []string{repeatRule.Kind.String()}
+	if repeatRule.Shift != "" {
+		groups = append(groups, model.ShiftModifier+"="+repeatRule.Shift)
+	}
+	if repeatRule.Leap != "" {
+		groups = append(groups, model.LeapModifier+"="+repeatRule.Leap)
+	}
+	//Отсчёт от даты задачи и пропуск просроченных повторений используются по умолчанию, поэтому в строку правила не попадают
+	if repeatRule.From != "" && repeatRule.From != model.FromDate {
+		groups = append(groups, model.FromModifier+"="+repeatRule.From)
+	}
+	if repeatRule.CatchUp != "" && repeatRule.CatchUp != model.CatchUpSkip {
+		groups = append(groups, model.CatchUpModifier+"="+repeatRule.CatchUp)
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// describeStoredRepeat описывает правило повторения, сохранённое у задачи, для некорректного правила описания нет
+func describeStoredRepeat(repeatRuleRaw string, lang string) string {
+	if repeatRuleRaw == "" {
+		return ""
+	}
+
+	//В базе данных может оказаться правило, сохранённое в обход проверки, а описание рассчитывает на проверенные значения
+	repeatRule, err := PrepareRepeatRuleFromRawString(repeatRuleRaw)
+	if err != nil || repeatRule.Kind == nil || repeatRule.Kind.Validate() != nil {
+		return ""
+	}
+
+	return DescribeRepeatRule(repeatRule, lang)
+}
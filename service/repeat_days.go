@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"go_final_project/service/model"
+	"strconv"
+	"time"
+)
+
+// daysRule правило d: через указанное число дней
+type daysRule struct {
+	days int
+}
+
+func parseDaysRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups[0]) != 1 {
+		return nil, errors.New("количество дней должно быть одним числом")
+	}
+
+	return daysRule{days: groups[0][0]}, nil
+}
+
+func (r daysRule) Validate() error {
+	if r.days > 400 || r.days < 1 {
+		return errors.New("формат правила повторения для D не соблюден")
+	}
+
+	return nil
+}
+
+func (r daysRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	nowDate := startOfDay(request.Now)
+	newDate := request.Date.AddDate(0, 0, r.days)
+
+	for newDate.Before(nowDate) {
+		newDate = newDate.AddDate(0, 0, r.days)
+	}
+
+	return newDate, nil
+}
+
+func (r daysRule) String() string {
+	return "d " + strconv.Itoa(r.days)
+}
+
+// workdaysRule правило bd: через указанное число рабочих дней
+type workdaysRule struct {
+	days int
+}
+
+func parseWorkdaysRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups[0]) != 1 {
+		return nil, errors.New("количество рабочих дней должно быть одним числом")
+	}
+
+	return workdaysRule{days: groups[0][0]}, nil
+}
+
+func (r workdaysRule) Validate() error {
+	if r.days > 400 || r.days < 1 {
+		return errors.New("формат правила повторения для BD не соблюден")
+	}
+
+	return nil
+}
+
+func (r workdaysRule) Next(calendar model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	nowDate := startOfDay(request.Now)
+	newDate := request.Date
+
+	for newDate.Equal(request.Date) || newDate.Before(nowDate) {
+		var err error
+		newDate, err = addWorkdays(calendar, newDate, r.days)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return newDate, nil
+}
+
+func (r workdaysRule) String() string {
+	return "bd " + strconv.Itoa(r.days)
+}
+
+// addWorkdays отсчитывает от даты указанное количество рабочих дней
+func addWorkdays(calendar model.WorkCalendar, date time.Time, workdaysCnt int) (time.Time, error) {
+	for i := 0; workdaysCnt > 0 && i < model.MaxSearchDays; i++ {
+		date = date.AddDate(0, 0, 1)
+		isWorkday, err := calendar.IsWorkday(date)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if isWorkday {
+			workdaysCnt--
+		}
+	}
+
+	if workdaysCnt > 0 {
+		return time.Time{}, errors.New("не удалось отсчитать рабочие дни, проверьте настройки рабочей недели")
+	}
+
+	return date, nil
+}
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"errors"
+	"go_final_project/config"
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// weekendsRule правило для проверки реестра: по выходным дням
+type weekendsRule struct{}
+
+func (weekendsRule) Validate() error {
+	return nil
+}
+
+func (weekendsRule) Next(calendar model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	date := request.Date
+	for i := 0; i < 7; i++ {
+		date = date.AddDate(0, 0, 1)
+		isWorkday, err := calendar.IsWorkday(date)
+		if err != nil || !isWorkday {
+			return date, err
+		}
+	}
+	return time.Time{}, errors.New("нет выходных")
+}
+
+func (weekendsRule) String() string {
+	return "we"
+}
+
+func TestRegisterRepeatKind(t *testing.T) {
+	service.RegisterRepeatKind("we", func(values []string) (model.RepeatKind, error) {
+		if len(values) > 0 {
+			return nil, errors.New("правило не принимает значений")
+		}
+		return weekendsRule{}, nil
+	})
+	svc := service.NewService(nil, nil, &config.Config{Workdays: []int{1, 2, 3, 4, 5}})
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString("we  shift=prev")
+	assert.NoError(t, err)
+	assert.Equal(t, "we shift=prev", service.FormatRepeatRule(repeatRule))
+
+	_, err = service.PrepareRepeatRuleFromRawString("we 1")
+	assert.Error(t, err)
+
+	date, err := time.Parse(model.CommonDateFormat, "20240126")
+	assert.NoError(t, err)
+	repeatRule, err = service.PrepareRepeatRuleFromRawString("we")
+	assert.NoError(t, err)
+	next, err := svc.CalculateNextDate(model.NextDateRequest{Now: date, Date: date, Repeat: repeatRule})
+	assert.NoError(t, err)
+	assert.Equal(t, "20240127", next.Format(model.CommonDateFormat))
+}
+
+// everyOtherDayRule вид правила для проверки реестра, описывает себя и переводит себя в RRULE
+type everyOtherDayRule struct{}
+
+func (everyOtherDayRule) Validate() error { return nil }
+
+func (everyOtherDayRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	return request.Date.AddDate(0, 0, 2), nil
+}
+
+func (everyOtherDayRule) String() string { return "eod" }
+
+func (everyOtherDayRule) Describe(lang string) string {
+	if lang == model.LangEn {
+		return "every other day"
+	}
+	return "через день"
+}
+
+func (everyOtherDayRule) RRuleParts() ([]string, error) {
+	return []string{"FREQ=DAILY", "INTERVAL=2"}, nil
+}
+
+// bareRule вид правила без описания и RRULE
+type bareRule struct{}
+
+func (bareRule) Validate() error { return nil }
+
+func (bareRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	return time.Time{}, errors.New("не используется")
+}
+
+func (bareRule) String() string { return "bare" }
+
+func TestRegisteredRepeatKind(t *testing.T) {
+	service.RegisterRepeatKind("eod", func(values []string) (model.RepeatKind, error) {
+		return everyOtherDayRule{}, nil
+	})
+	service.RegisterRepeatKind("bare", func(values []string) (model.RepeatKind, error) {
+		return bareRule{}, nil
+	})
+
+	rule, err := service.PrepareRepeatRuleFromRawString("eod")
+	assert.NoError(t, err)
+	assert.Equal(t, "через день", service.DescribeRepeatRule(rule, model.LangRu))
+	assert.Equal(t, "every other day", service.DescribeRepeatRule(rule, model.LangEn))
+	rrule, err := service.ConvertRepeatRuleToRRule(rule)
+	assert.NoError(t, err)
+	assert.Equal(t, "RRULE:FREQ=DAILY;INTERVAL=2", rrule)
+
+	rule, err = service.PrepareRepeatRuleFromRawString("bare")
+	assert.NoError(t, err)
+	assert.Empty(t, service.DescribeRepeatRule(rule, model.LangRu))
+	_, err = service.ConvertRepeatRuleToRRule(rule)
+	assert.Error(t, err)
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"errors"
+	"go_final_project/service/model"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// monthDaysRule правило m: в указанные дни месяца (-1 - последний, -2 - предпоследний), можно только в указанные месяцы
+type monthDaysRule struct {
+	days   []int
+	months []int
+}
+
+func parseMonthDaysRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	//Дни с конца месяца записываем после обычных: сначала последний, затем предпоследний
+	dayKey := func(day int) int {
+		if day < 0 {
+			return 100 - day
+		}
+		return day
+	}
+	days := sortedUnique(groups[0])
+	slices.SortFunc(days, func(a, b int) int {
+		return dayKey(a) - dayKey(b)
+	})
+
+	rule := monthDaysRule{days: days}
+	if len(groups) == 2 {
+		rule.months = sortedUnique(groups[1])
+	}
+
+	return rule, nil
+}
+
+func (r monthDaysRule) Validate() error {
+	for _, mValDay := range r.days {
+		if mValDay == 0 || mValDay < -2 || mValDay > 31 {
+			return errors.New("формат правила повторения для M обозначающего номер дня не соблюден")
+		}
+	}
+
+	for _, mValMonth := range r.months {
+		if mValMonth < 1 || mValMonth > 12 {
+			return errors.New("формат правила повторения для M обозначающего номер месяца не соблюден")
+		}
+	}
+
+	return nil
+}
+
+func (r monthDaysRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	currentDate := request.Date
+	nowDate := startOfDay(request.Now)
+	needExactMonth := len(r.months) > 0
+
+	repeatDaysMap := make(map[int]bool, len(r.days))
+	repeatMonthsMap := make(map[int]bool, len(r.months))
+	hasLastDayRepeat := false
+	hasPreLastDayRepeat := false
+	for _, dVal := range r.days {
+		if dVal > 0 {
+			repeatDaysMap[dVal] = true
+		} else if dVal == -1 {
+			hasLastDayRepeat = true
+		} else if dVal == -2 {
+			hasPreLastDayRepeat = true
+		}
+	}
+	for _, mVal := range r.months {
+		repeatMonthsMap[mVal] = true
+	}
+
+	isCurrentDatePast := currentDate.Before(nowDate)
+
+	//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты
+	if isCurrentDatePast {
+		currentDate = nowDate
+	}
+
+	newDay := currentDate.Day()
+	newMonth := int(currentDate.Month())
+
+	lastMonthDay, preLastMonthDay := get2LastMonthDays(currentDate)
+
+	newDate := currentDate
+	//Если дата задачи была в прошлом и сегодняшняя дата попадает под правило повторения
+	if isCurrentDatePast && (!needExactMonth || repeatMonthsMap[newMonth]) {
+		if hasPreLastDayRepeat && newDay == preLastMonthDay {
+			return newDate, nil
+		}
+		if hasLastDayRepeat && newDay == lastMonthDay {
+			return newDate, nil
+		}
+		if repeatDaysMap[newDay] {
+			return newDate, nil
+		}
+	}
+
+	for i := 0; i < model.MaxSearchDays; i++ {
+		newDate = newDate.AddDate(0, 0, 1)
+		if int(newDate.Month()) != newMonth {
+			lastMonthDay, preLastMonthDay = get2LastMonthDays(newDate)
+			newMonth = int(newDate.Month())
+		}
+
+		if !needExactMonth || repeatMonthsMap[newMonth] {
+			newDay = newDate.Day()
+			if hasPreLastDayRepeat && newDay == preLastMonthDay {
+				return newDate, nil
+			}
+			if hasLastDayRepeat && newDay == lastMonthDay {
+				return newDate, nil
+			}
+			if repeatDaysMap[newDay] {
+				return newDate, nil
+			}
+		}
+	}
+
+	return time.Time{}, errors.New("не удалось найти дату, подходящую под правило повторения")
+}
+
+func (r monthDaysRule) String() string {
+	if len(r.months) > 0 {
+		return "m " + joinInts(r.days) + " " + joinInts(r.months)
+	}
+
+	return "m " + joinInts(r.days)
+}
+
+// monthsIntervalRule правило mi: раз в несколько месяцев, считая от даты задачи.
+// День 0 означает день исходной даты задачи, -1 - последний день месяца
+type monthsIntervalRule struct {
+	interval int
+	day      int
+}
+
+func parseMonthsIntervalRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups[0]) != 1 {
+		return nil, errors.New("интервал в месяцах должен быть одним числом")
+	}
+
+	rule := monthsIntervalRule{interval: groups[0][0]}
+	if len(groups) == 2 {
+		if len(groups[1]) != 1 {
+			return nil, errors.New("номер дня должен быть одним числом")
+		}
+		rule.day = groups[1][0]
+		//0 зарезервирован под день исходной даты, поэтому явно переданный 0 сразу считаем ошибочным
+		if rule.day == 0 {
+			return nil, errors.New("номер дня не может быть равен 0")
+		}
+	}
+
+	return rule, nil
+}
+
+func (r monthsIntervalRule) Validate() error {
+	if r.interval < 1 || r.interval > model.MaxMonthsInterval {
+		return errors.New("формат правила повторения для MI обозначающего интервал в месяцах не соблюден")
+	}
+
+	if r.day < -1 || r.day > 31 {
+		return errors.New("формат правила повторения для MI обозначающего номер дня не соблюден")
+	}
+
+	return nil
+}
+
+func (r monthsIntervalRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	nowDate := startOfDay(request.Now)
+
+	//Месяцы отсчитываются от исходной даты задачи, день по умолчанию берётся из неё же
+	anchorDate := request.Date
+	day := r.day
+	if day == 0 {
+		day = anchorDate.Day()
+	}
+
+	for months := 0; ; months += r.interval {
+		newDate := monthDate(anchorDate.Year(), anchorDate.Month()+time.Month(months), day)
+		if newDate.After(request.Date) && !newDate.Before(nowDate) {
+			return newDate, nil
+		}
+	}
+}
+
+func (r monthsIntervalRule) String() string {
+	if r.day != 0 {
+		return "mi " + strconv.Itoa(r.interval) + " " + strconv.Itoa(r.day)
+	}
+
+	return "mi " + strconv.Itoa(r.interval)
+}
+
+// monthWeekdaysRule правило mw: в указанный по счёту день недели месяца, можно только в указанные месяцы
+type monthWeekdaysRule struct {
+	ordinals []model.WeekdayOrdinal
+	months   []int
+}
+
+func parseMonthWeekdaysRule(values []string) (model.RepeatKind, error) {
+	if len(values) == 0 || len(values) > 2 {
+		return nil, errors.New("ожидается от 1 до 2 групп значений")
+	}
+
+	ordinals, err := parseWeekdayOrdinalsFromString(values[0])
+	if err != nil {
+		return nil, err
+	}
+	//Последний день недели месяца записываем после пятого
+	ordinalKey := func(ordinal model.WeekdayOrdinal) int {
+		if ordinal.Ordinal == model.LastWeekdayOrdinal {
+			return 6*10 + ordinal.Weekday
+		}
+		return ordinal.Ordinal*10 + ordinal.Weekday
+	}
+	slices.SortFunc(ordinals, func(a, b model.WeekdayOrdinal) int {
+		return ordinalKey(a) - ordinalKey(b)
+	})
+
+	rule := monthWeekdaysRule{ordinals: slices.Compact(ordinals)}
+	if len(values) == 2 {
+		months, err := parseRepeatValuesFromString(values[1])
+		if err != nil {
+			return nil, err
+		}
+		rule.months = sortedUnique(months)
+	}
+
+	return rule, nil
+}
+
+func (r monthWeekdaysRule) Validate() error {
+	for _, ordinal := range r.ordinals {
+		if ordinal.Ordinal != model.LastWeekdayOrdinal && (ordinal.Ordinal < 1 || ordinal.Ordinal > 5) {
+			return errors.New("формат правила повторения для MW обозначающего номер дня недели в месяце не соблюден")
+		}
+		if ordinal.Weekday < 1 || ordinal.Weekday > 7 {
+			return errors.New("формат правила повторения для MW обозначающего день недели не соблюден")
+		}
+	}
+
+	for _, mValMonth := range r.months {
+		if mValMonth < 1 || mValMonth > 12 {
+			return errors.New("формат правила повторения для MW обозначающего номер месяца не соблюден")
+		}
+	}
+
+	return nil
+}
+
+func (r monthWeekdaysRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	currentDate := request.Date
+	nowDate := startOfDay(request.Now)
+
+	isCurrentDatePast := currentDate.Before(nowDate)
+
+	//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты, и сегодняшний день тоже может подойти
+	if isCurrentDatePast {
+		currentDate = nowDate
+	}
+
+	return findNextDay(currentDate, isCurrentDatePast, func(date time.Time) bool {
+		if len(r.months) > 0 && !slices.Contains(r.months, int(date.Month())) {
+			return false
+		}
+		for _, ordinal := range r.ordinals {
+			if isWeekdayOrdinalMatch(date, ordinal) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func (r monthWeekdaysRule) String() string {
+	ordinals := make([]string, 0, len(r.ordinals))
+	for _, ordinal := range r.ordinals {
+		ordinalStr := strconv.Itoa(ordinal.Ordinal)
+		if ordinal.Ordinal == model.LastWeekdayOrdinal {
+			ordinalStr = model.LastWeekdayOrdinalRaw
+		}
+		ordinals = append(ordinals, ordinalStr+":"+strconv.Itoa(ordinal.Weekday))
+	}
+
+	if len(r.months) > 0 {
+		return "mw " + strings.Join(ordinals, ",") + " " + joinInts(r.months)
+	}
+
+	return "mw " + strings.Join(ordinals, ",")
+}
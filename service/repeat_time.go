@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_final_project/service/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeIntervalRule правила h и min: раз в несколько часов или минут, считая от даты и времени задачи
+type timeIntervalRule struct {
+	name     string
+	unit     time.Duration
+	interval int
+	max      int
+}
+
+func parseHoursRule(values []string) (model.RepeatKind, error) {
+	return parseTimeIntervalRule(timeIntervalRule{name: "h", unit: time.Hour, max: model.MaxHoursInterval}, values)
+}
+
+func parseMinutesRule(values []string) (model.RepeatKind, error) {
+	return parseTimeIntervalRule(timeIntervalRule{name: "min", unit: time.Minute, max: model.MaxMinutesInterval}, values)
+}
+
+func parseTimeIntervalRule(rule timeIntervalRule, values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups[0]) != 1 {
+		return nil, errors.New("интервал должен быть одним числом")
+	}
+	rule.interval = groups[0][0]
+
+	return rule, nil
+}
+
+func (r timeIntervalRule) Validate() error {
+	if r.interval < 1 || r.interval > r.max {
+		return fmt.Errorf("формат правила повторения для %s не соблюден", strings.ToUpper(r.name))
+	}
+
+	return nil
+}
+
+// Next возвращает ближайшее повторение не раньше текущего момента, а не начала сегодняшнего дня
+func (r timeIntervalRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	step := time.Duration(r.interval) * r.unit
+	newDate := request.Date.Add(step)
+
+	//Пропущенные повторения отсчитываем сразу, а не по одному
+	if newDate.Before(request.Now) {
+		newDate = newDate.Add(request.Now.Sub(newDate) / step * step)
+		if newDate.Before(request.Now) {
+			newDate = newDate.Add(step)
+		}
+	}
+
+	return newDate, nil
+}
+
+func (r timeIntervalRule) String() string {
+	return r.name + " " + strconv.Itoa(r.interval)
+}
+
+// IsIntradayRule проверяет, что правило повторяет задачу несколько раз в день, и для него важно время задачи
+func IsIntradayRule(repeatRule model.RepeatRule) bool {
+	_, ok := repeatRule.Kind.(timeIntervalRule)
+
+	return ok
+}
+
+// withTime добавляет к дате время задачи в формате model.TimeFormat
+func withTime(date time.Time, timeStr string) (time.Time, error) {
+	if timeStr == "" {
+		return date, nil
+	}
+
+	taskTime, err := time.Parse(model.TimeFormat, timeStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), taskTime.Hour(), taskTime.Minute(), 0, 0, date.Location()), nil
+}
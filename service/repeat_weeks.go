@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"go_final_project/service/model"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// weeksRule правило w: в указанные дни недели, при интервале больше 1 - раз в несколько недель
+type weeksRule struct {
+	weekdays []int
+	interval int
+}
+
+func parseWeeksRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := weeksRule{weekdays: sortedUnique(groups[0]), interval: 1}
+	if len(groups) == 2 {
+		if len(groups[1]) != 1 {
+			return nil, errors.New("интервал в неделях должен быть одним числом")
+		}
+		rule.interval = groups[1][0]
+	}
+
+	return rule, nil
+}
+
+func (r weeksRule) Validate() error {
+	for _, wVal := range r.weekdays {
+		if wVal < 1 || wVal > 7 {
+			return errors.New("формат правила повторения для W не соблюден")
+		}
+	}
+
+	if r.interval < 1 || r.interval > 52 {
+		return errors.New("формат правила повторения для W обозначающего интервал в неделях не соблюден")
+	}
+
+	return nil
+}
+
+func (r weeksRule) Next(_ model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	currentDate := request.Date
+	nowDate := startOfDay(request.Now)
+
+	//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты
+	if currentDate.Before(nowDate) {
+		currentDate = nowDate
+	}
+
+	//Для правила с интервалом недели отсчитываются от исходной даты задачи, чтобы не сбить чётность при позднем выполнении
+	if r.interval > 1 {
+		anchorWeekStart := weekStart(request.Date)
+
+		return findNextDay(currentDate, false, func(date time.Time) bool {
+			if !slices.Contains(r.weekdays, weekdayNumber(date)) {
+				return false
+			}
+
+			return daysBetween(anchorWeekStart, weekStart(date))/7%r.interval == 0
+		})
+	}
+
+	//По условию задачи воскресенье считаем седьмым днём, а в time оно забито как 0
+	currentWeekday := int(currentDate.Weekday())
+
+	var closestWeekdaysInThisWeek []int
+	var closestWeekday int
+	var addDaysCnt int
+	for _, wVal := range r.weekdays {
+		if wVal > currentWeekday {
+			//Почему-то в тестах для w считается, что сегодняшний день недели должен быть пропущен, даже если подходит по условию
+			//|| (isCurrentDatePast && wVal == currentWeekday) - если нужно учесть сегодняшний день недели
+			closestWeekdaysInThisWeek = append(closestWeekdaysInThisWeek, wVal)
+		}
+	}
+	if len(closestWeekdaysInThisWeek) > 0 {
+		closestWeekday = slices.Min(closestWeekdaysInThisWeek)
+	} else {
+		//если следующий день по плану находится на следующей неделе
+		closestWeekday = slices.Min(r.weekdays)
+	}
+
+	if closestWeekday <= currentWeekday {
+		addDaysCnt = closestWeekday - (currentWeekday - 7)
+	} else {
+		addDaysCnt = closestWeekday - currentWeekday
+	}
+
+	return currentDate.AddDate(0, 0, addDaysCnt), nil
+}
+
+func (r weeksRule) String() string {
+	if r.interval > 1 {
+		return "w " + joinInts(r.weekdays) + " " + strconv.Itoa(r.interval)
+	}
+
+	return "w " + joinInts(r.weekdays)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_final_project/service/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// yearsRule правило y: ежегодно в день исходной даты или в указанные даты в формате MMDD,
+// при интервале больше 1 - раз в несколько лет, считая от года даты задачи
+type yearsRule struct {
+	dates    []int
+	interval int
+}
+
+func parseYearsRule(values []string) (model.RepeatKind, error) {
+	groups, err := parseRepeatGroups(values, 0, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := yearsRule{interval: 1}
+	if len(groups) > 0 {
+		rule.dates = sortedUnique(groups[0])
+	}
+	if len(groups) == 2 {
+		if len(groups[1]) != 1 {
+			return nil, errors.New("интервал в годах должен быть одним числом")
+		}
+		rule.interval = groups[1][0]
+	}
+
+	return rule, nil
+}
+
+func (r yearsRule) Validate() error {
+	for _, yVal := range r.dates {
+		//Проверяем дату по високосному году, чтобы 29 февраля тоже считалось допустимым
+		month, day := yVal/100, yVal%100
+		date := time.Date(2024, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if month < 1 || month > 12 || day < 1 || date.Day() != day {
+			return errors.New("формат правила повторения для Y обозначающего дату в формате MMDD не соблюден")
+		}
+	}
+
+	if r.interval < 1 || r.interval > model.MaxYearsInterval {
+		return errors.New("формат правила повторения для Y обозначающего интервал в годах не соблюден")
+	}
+
+	return nil
+}
+
+func (r yearsRule) Next(calendar model.WorkCalendar, request model.NextDateRequest) (time.Time, error) {
+	currentDate := request.Date
+	nowDate := startOfDay(request.Now)
+
+	leap := request.Repeat.Leap
+	if leap == "" {
+		leap = calendar.LeapDay()
+	}
+
+	//Без списка дат задача повторяется в день и месяц исходной даты
+	if len(r.dates) == 0 {
+		for years := r.interval; ; years += r.interval {
+			newDate := yearlyDate(currentDate.Year()+years, currentDate.Month(), currentDate.Day(), leap)
+			if !newDate.Before(nowDate) {
+				return newDate, nil
+			}
+		}
+	}
+
+	//Годы отсчитываются от года исходной даты задачи, поэтому первый подходящий год не позже года сегодняшней даты + интервал
+	lastYear := max(currentDate.Year(), nowDate.Year()) + r.interval
+	for year := currentDate.Year(); year <= lastYear; year += r.interval {
+		for _, yVal := range r.dates {
+			date := yearlyDate(year, time.Month(yVal/100), yVal%100, leap)
+			if date.After(currentDate) && !date.Before(nowDate) {
+				return date, nil
+			}
+		}
+	}
+
+	return time.Time{}, errors.New("не удалось найти дату, подходящую под правило повторения")
+}
+
+func (r yearsRule) String() string {
+	if len(r.dates) == 0 {
+		return "y"
+	}
+
+	dates := make([]string, 0, len(r.dates))
+	for _, yVal := range r.dates {
+		dates = append(dates, fmt.Sprintf("%04d", yVal))
+	}
+	if r.interval > 1 {
+		return "y " + strings.Join(dates, ",") + " " + strconv.Itoa(r.interval)
+	}
+
+	return "y " + strings.Join(dates, ",")
+}
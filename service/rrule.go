@@ -0,0 +1,350 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_final_project/service/model"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var rruleWeekdays = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// IsRRule проверяет, что правило повторения передано в формате RRULE из RFC 5545
+func IsRRule(repeatRuleRaw string) bool {
+	return strings.HasPrefix(strings.ToUpper(repeatRuleRaw), model.RRulePrefix)
+}
+
+// prepareRepeatRuleFromRRule переводит RRULE во внутреннее правило повторения, по которому считается следующая дата
+func prepareRepeatRuleFromRRule(rruleRaw string) (model.RepeatRule, error) {
+	parts, err := parseRRuleParts(rruleRaw)
+	if err != nil {
+		return model.RepeatRule{}, err
+	}
+
+	interval := 1
+	if intervalRaw, ok := parts["INTERVAL"]; ok {
+		interval, err = strconv.Atoi(intervalRaw)
+		if err != nil || interval < 1 {
+			return model.RepeatRule{}, fmt.Errorf("некорректное значение INTERVAL: %s", intervalRaw)
+		}
+	}
+
+	name, values, err := prepareRepeatValuesFromRRule(parts, interval)
+	if err != nil {
+		return model.RepeatRule{}, err
+	}
+
+	//Значения RRULE передаются тем же видам правил, что и внутренний формат, чтобы правило нормализовалось одинаково
+	kind, err := repeatKinds[name](values)
+	if err != nil {
+		return model.RepeatRule{}, fmt.Errorf("некорректное значение параметра RRULE: %s", err.Error())
+	}
+
+	return model.RepeatRule{Name: name, Kind: kind}, nil
+}
+
+// prepareRepeatValuesFromRRule подбирает по параметрам RRULE имя внутреннего правила и группы его значений
+func prepareRepeatValuesFromRRule(parts map[string]string, interval int) (string, []string, error) {
+	intervalRaw := strconv.Itoa(interval)
+	byDay, hasByDay := parts["BYDAY"]
+	byMonthDay, hasByMonthDay := parts["BYMONTHDAY"]
+	byMonth, hasByMonth := parts["BYMONTH"]
+
+	switch parts["FREQ"] {
+	case "DAILY":
+		if hasByDay || hasByMonthDay || hasByMonth {
+			return "", nil, errors.New("для FREQ=DAILY не поддерживаются параметры BY*")
+		}
+
+		return "d", []string{intervalRaw}, nil
+	case "WEEKLY":
+		if hasByMonthDay || hasByMonth {
+			return "", nil, errors.New("для FREQ=WEEKLY поддерживается только параметр BYDAY")
+		}
+		//Без BYDAY задача повторяется в тот же день недели, что и исходная дата
+		if !hasByDay {
+			return "d", []string{strconv.Itoa(7 * interval)}, nil
+		}
+
+		ordinals, err := parseRRuleByDay(byDay)
+		if err != nil {
+			return "", nil, err
+		}
+		weekdays := make([]int, 0, len(ordinals))
+		for _, ordinal := range ordinals {
+			if ordinal.Ordinal != 0 {
+				return "", nil, errors.New("для FREQ=WEEKLY в BYDAY не поддерживаются порядковые номера")
+			}
+			weekdays = append(weekdays, ordinal.Weekday)
+		}
+
+		return "w", []string{joinInts(weekdays), intervalRaw}, nil
+	case "MONTHLY":
+		//Без BYDAY и BYMONTHDAY или с интервалом задача повторяется раз в несколько месяцев от исходной даты
+		if !hasByDay && !hasByMonth && (!hasByMonthDay || interval > 1) {
+			if !hasByMonthDay {
+				return "mi", []string{intervalRaw}, nil
+			}
+			if strings.Contains(byMonthDay, ",") {
+				return "", nil, errors.New("для FREQ=MONTHLY с INTERVAL поддерживается только один день в BYMONTHDAY")
+			}
+
+			return "mi", []string{intervalRaw, byMonthDay}, nil
+		}
+		if interval > 1 {
+			return "", nil, errors.New("для FREQ=MONTHLY с INTERVAL больше 1 не поддерживаются BYDAY и BYMONTH")
+		}
+		if hasByDay == hasByMonthDay {
+			return "", nil, errors.New("для FREQ=MONTHLY нужно указать либо BYMONTHDAY, либо BYDAY")
+		}
+
+		var values []string
+		name := "m"
+		if hasByMonthDay {
+			values = append(values, byMonthDay)
+		} else {
+			ordinals, err := parseRRuleByDay(byDay)
+			if err != nil {
+				return "", nil, err
+			}
+
+			ordinalsRaw := make([]string, 0, len(ordinals))
+			for _, ordinal := range ordinals {
+				if ordinal.Ordinal == 0 {
+					return "", nil, errors.New("для FREQ=MONTHLY в BYDAY нужно указать порядковый номер дня недели")
+				}
+				ordinalRaw := strconv.Itoa(ordinal.Ordinal)
+				if ordinal.Ordinal == model.LastWeekdayOrdinal {
+					ordinalRaw = model.LastWeekdayOrdinalRaw
+				}
+				ordinalsRaw = append(ordinalsRaw, ordinalRaw+":"+strconv.Itoa(ordinal.Weekday))
+			}
+			name = "mw"
+			values = append(values, strings.Join(ordinalsRaw, ","))
+		}
+		if hasByMonth {
+			values = append(values, byMonth)
+		}
+
+		return name, values, nil
+	case "YEARLY":
+		if hasByDay {
+			return "", nil, errors.New("для FREQ=YEARLY не поддерживается параметр BYDAY")
+		}
+		if !hasByMonth && !hasByMonthDay {
+			if interval > 1 {
+				return "", nil, errors.New("для FREQ=YEARLY с INTERVAL нужно указать BYMONTH и BYMONTHDAY")
+			}
+
+			return "y", nil, nil
+		}
+		if !hasByMonth || !hasByMonthDay {
+			return "", nil, errors.New("для FREQ=YEARLY нужно указать и BYMONTH, и BYMONTHDAY")
+		}
+
+		months, err := parseRRuleInts(byMonth)
+		if err != nil {
+			return "", nil, err
+		}
+		days, err := parseRRuleInts(byMonthDay)
+		if err != nil {
+			return "", nil, err
+		}
+
+		//Каждый день из BYMONTHDAY повторяется в каждом месяце из BYMONTH
+		yVals := make([]int, 0, len(months)*len(days))
+		for _, month := range months {
+			for _, day := range days {
+				yVals = append(yVals, month*100+day)
+			}
+		}
+
+		return "y", []string{joinInts(yVals), intervalRaw}, nil
+	}
+
+	return "", nil, fmt.Errorf("неподдерживаемое значение FREQ: %s", parts["FREQ"])
+}
+
+// ConvertRepeatRuleToRRule переводит внутреннее правило повторения в эквивалентное RRULE
+func ConvertRepeatRuleToRRule(repeatRule model.RepeatRule) (string, error) {
+	if repeatRule.Shift != "" {
+		return "", errors.New("перенос на рабочий день нельзя выразить в формате RRULE")
+	}
+	if repeatRule.Leap != "" {
+		return "", errors.New("перенос 29 февраля нельзя выразить в формате RRULE")
+	}
+	if repeatRule.From == model.FromDone {
+		return "", errors.New("отсчёт от дня выполнения нельзя выразить в формате RRULE")
+	}
+	if repeatRule.CatchUp != "" && repeatRule.CatchUp != model.CatchUpSkip {
+		return "", errors.New("политику пропущенных повторений нельзя выразить в формате RRULE")
+	}
+
+	var parts []string
+
+	switch rule := repeatRule.Kind.(type) {
+	case daysRule:
+		parts = append(parts, "FREQ=DAILY")
+		if rule.days > 1 {
+			parts = append(parts, "INTERVAL="+strconv.Itoa(rule.days))
+		}
+	case weeksRule:
+		parts = append(parts, "FREQ=WEEKLY")
+		if rule.interval > 1 {
+			parts = append(parts, "INTERVAL="+strconv.Itoa(rule.interval))
+		}
+		byDay := make([]string, 0, len(rule.weekdays))
+		for _, wVal := range rule.weekdays {
+			byDay = append(byDay, rruleWeekdays[wVal-1])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(byDay, ","))
+	case monthDaysRule:
+		parts = append(parts, "FREQ=MONTHLY", "BYMONTHDAY="+joinInts(rule.days))
+		if len(rule.months) > 0 {
+			parts = append(parts, "BYMONTH="+joinInts(rule.months))
+		}
+	case monthsIntervalRule:
+		parts = append(parts, "FREQ=MONTHLY")
+		if rule.interval > 1 {
+			parts = append(parts, "INTERVAL="+strconv.Itoa(rule.interval))
+		}
+		//В RRULE несуществующие даты пропускаются, а правило mi переносит их на последний день месяца
+		if rule.day > 28 {
+			return "", errors.New("день после 28-го числа в правиле mi нельзя выразить в формате RRULE")
+		}
+		if rule.day != 0 {
+			parts = append(parts, "BYMONTHDAY="+strconv.Itoa(rule.day))
+		}
+	case monthWeekdaysRule:
+		byDay := make([]string, 0, len(rule.ordinals))
+		for _, ordinal := range rule.ordinals {
+			byDay = append(byDay, strconv.Itoa(ordinal.Ordinal)+rruleWeekdays[ordinal.Weekday-1])
+		}
+		parts = append(parts, "FREQ=MONTHLY", "BYDAY="+strings.Join(byDay, ","))
+		if len(rule.months) > 0 {
+			parts = append(parts, "BYMONTH="+joinInts(rule.months))
+		}
+	case yearsRule:
+		parts = append(parts, "FREQ=YEARLY")
+		if len(rule.dates) == 0 {
+			break
+		}
+		if rule.interval > 1 {
+			parts = append(parts, "INTERVAL="+strconv.Itoa(rule.interval))
+		}
+
+		//В RRULE дни из BYMONTHDAY повторяются в каждом месяце, поэтому даты в разных месяцах должны иметь одинаковые дни
+		months, days := yearDatesMonthsAndDays(rule.dates)
+		if len(months)*len(days) != len(rule.dates) {
+			return "", errors.New("даты правила y нельзя выразить через BYMONTH и BYMONTHDAY")
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months), "BYMONTHDAY="+joinInts(days))
+	default:
+		//Виды правил, добавленные через RegisterRepeatKind, переводят себя сами
+		converter, ok := repeatRule.Kind.(model.RepeatKindRRuleConverter)
+		if !ok {
+			return "", fmt.Errorf("для правила %s нет эквивалента в формате RRULE", repeatRule.Name)
+		}
+		kindParts, err := converter.RRuleParts()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, kindParts...)
+	}
+
+	return model.RRulePrefix + strings.Join(parts, ";"), nil
+}
+
+func parseRRuleParts(rruleRaw string) (map[string]string, error) {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.ToUpper(rruleRaw)[len(model.RRulePrefix):], ";") {
+		if part == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("параметр RRULE %s должен быть в формате КЛЮЧ=ЗНАЧЕНИЕ", part)
+		}
+
+		switch key {
+		case "FREQ", "INTERVAL", "BYDAY", "BYMONTHDAY", "BYMONTH":
+		default:
+			return nil, fmt.Errorf("параметр RRULE %s не поддерживается", key)
+		}
+
+		if _, ok := parts[key]; ok {
+			return nil, fmt.Errorf("параметр RRULE %s указан несколько раз", key)
+		}
+		parts[key] = value
+	}
+
+	if _, ok := parts["FREQ"]; !ok {
+		return nil, errors.New("в RRULE не указан обязательный параметр FREQ")
+	}
+
+	return parts, nil
+}
+
+// parseRRuleByDay разбирает BYDAY, Ordinal равен 0, если порядковый номер не указан
+func parseRRuleByDay(byDay string) ([]model.WeekdayOrdinal, error) {
+	byDaySlice := strings.Split(byDay, ",")
+	ordinals := make([]model.WeekdayOrdinal, 0, len(byDaySlice))
+
+	for _, day := range byDaySlice {
+		if len(day) < 2 {
+			return nil, fmt.Errorf("некорректное значение BYDAY: %s", day)
+		}
+
+		weekday := 0
+		for i, rruleWeekday := range rruleWeekdays {
+			if rruleWeekday == day[len(day)-2:] {
+				weekday = i + 1
+			}
+		}
+		if weekday == 0 {
+			return nil, fmt.Errorf("некорректный день недели в BYDAY: %s", day)
+		}
+
+		ordinal := 0
+		if ordinalRaw := day[:len(day)-2]; ordinalRaw != "" {
+			ordinalInt, err := strconv.Atoi(ordinalRaw)
+			if err != nil {
+				return nil, fmt.Errorf("некорректный порядковый номер в BYDAY: %s", day)
+			}
+			ordinal = ordinalInt
+		}
+
+		ordinals = append(ordinals, model.WeekdayOrdinal{Ordinal: ordinal, Weekday: weekday})
+	}
+
+	return ordinals, nil
+}
+
+func parseRRuleInts(rValsString string) ([]int, error) {
+	rVals, err := parseRepeatValuesFromString(rValsString)
+	if err != nil {
+		return nil, fmt.Errorf("некорректное значение параметра RRULE %s: %s", rValsString, err.Error())
+	}
+
+	return rVals, nil
+}
+
+// yearDatesMonthsAndDays раскладывает даты в формате MMDD на уникальные месяцы и дни
+func yearDatesMonthsAndDays(yVals []int) ([]int, []int) {
+	var months, days []int
+	for _, yVal := range yVals {
+		if !slices.Contains(months, yVal/100) {
+			months = append(months, yVal/100)
+		}
+		if !slices.Contains(days, yVal%100) {
+			days = append(days, yVal%100)
+		}
+	}
+	slices.Sort(months)
+	slices.Sort(days)
+
+	return months, days
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"go_final_project/config"
 	"go_final_project/database"
 	"go_final_project/service/model"
 	"slices"
@@ -10,191 +12,278 @@ import (
 )
 
 type Service struct {
-	storage *database.DBStorage
+	storage  database.Storage
+	holidays HolidayCalendar
+	workdays map[int]bool
+	leapDay  string
+	location *time.Location
+	clock    Clock
+	status   model.StatusResponse
+	// trashRetention сколько удалённые задачи хранятся в корзине
+	trashRetention time.Duration
 }
 
-func NewService(storage *database.DBStorage) *Service {
+func NewService(storage database.Storage, holidays HolidayCalendar, cfg *config.Config) *Service {
+	workdays := make(map[int]bool, len(cfg.Workdays))
+	for _, workday := range cfg.Workdays {
+		workdays[workday] = true
+	}
+
+	var clock Clock = SystemClock{}
+	if !cfg.Now.IsZero() {
+		clock = FixedClock{Time: cfg.Now}
+	}
+
 	return &Service{
-		storage: storage,
+		storage:  storage,
+		holidays: holidays,
+		workdays: workdays,
+		leapDay:  cfg.LeapDay,
+		location: cfg.Timezone,
+		clock:    clock,
+		status:   model.StatusResponse{Storage: cfg.Storage, Driver: config.StorageDrivers[cfg.Storage]},
+
+		trashRetention: cfg.TrashRetention,
+	}
+}
+
+// SetClock заменяет часы сервиса, например чтобы воспроизвести поведение просроченных задач
+func (s *Service) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// Now возвращает текущие дату и время в указанном часовом поясе, а если он не передан - в часовом поясе планировщика.
+// Даты задач хранятся без часового пояса и разбираются DateParse в UTC, поэтому показания часов тоже переносятся в UTC
+func (s *Service) Now(location *time.Location) time.Time {
+	if location == nil {
+		location = s.location
+	}
+	if location == nil {
+		location = time.Local
+	}
+
+	return wallClock(s.clock.Now().In(location))
+}
+
+// requestNow возвращает текущий момент для запроса: подменённый в запросе, если он передан, иначе по часам сервиса
+func (s *Service) requestNow(debugNow time.Time, location *time.Location) time.Time {
+	if !debugNow.IsZero() {
+		return debugNow
 	}
+
+	return s.Now(location)
 }
 
 // CalculateNextDate вычисляет корректную новую дату задания на основе переданного правила повторения
 func (s *Service) CalculateNextDate(nextDateRequest model.NextDateRequest) (time.Time, error) {
-	var newDate time.Time
-	currentDate := nextDateRequest.Date
-	now := nextDateRequest.Now
-	nowDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-
-	switch nextDateRequest.Repeat.Name {
-	case "d":
-		dVal := nextDateRequest.Repeat.Values[0][0]
-		newDate = currentDate.AddDate(0, 0, dVal)
-
-		for newDate.Before(nowDate) {
-			newDate = newDate.AddDate(0, 0, dVal)
+	newDate, err := s.calculateShiftedNextDate(nextDateRequest)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	//Исключённые даты пропускаем и берём следующую дату по правилу, для правил h и min в один день бывает несколько повторений
+	for i := 0; i < model.MaxSearchDays && slices.Contains(nextDateRequest.Exceptions, newDate.Format(model.CommonDateFormat)); i++ {
+		nextDateRequest.Date = newDate
+		nextDateRequest.Now = newDate
+		newDate, err = s.calculateShiftedNextDate(nextDateRequest)
+		if err != nil {
+			return time.Time{}, err
 		}
-	case "w":
-		wVals := nextDateRequest.Repeat.Values[0]
-		isCurrentDatePast := currentDate.Before(nowDate)
+	}
+
+	return newDate, nil
+}
+
+// calculateShiftedNextDate вычисляет следующую дату задания с учётом переноса на рабочий день
+func (s *Service) calculateShiftedNextDate(nextDateRequest model.NextDateRequest) (time.Time, error) {
+	if nextDateRequest.Repeat.Shift == "" {
+		return s.calculateRuleNextDate(nextDateRequest)
+	}
 
-		//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты
-		if isCurrentDatePast {
-			currentDate = nowDate
+	nowDate := startOfDay(nextDateRequest.Now)
+
+	//Перенесённая дата может оказаться не позже исходной (например, 15е число перенесли на 14е),
+	//тогда берём следующую дату по правилу
+	ruleRequest := nextDateRequest
+	for i := 0; i < model.MaxSearchDays; i++ {
+		ruleDate, err := s.calculateRuleNextDate(ruleRequest)
+		if err != nil {
+			return time.Time{}, err
 		}
 
-		//По условию задачи воскресенье считаем седьмым днём, а в time оно забито как 0
-		currentWeekday := int(currentDate.Weekday())
-
-		var closestWeekdaysInThisWeek []int
-		var closestWeekday int
-		var addDaysCnt int
-		for _, wVal := range wVals {
-			if wVal > currentWeekday {
-				//Почему-то в тестах для w считается, что сегодняшний день недели должен быть пропущен, даже если подходит по условию
-				//|| (isCurrentDatePast && wVal == currentWeekday) - если нужно учесть сегодняшний день недели
-				closestWeekdaysInThisWeek = append(closestWeekdaysInThisWeek, wVal)
-			}
+		shiftedDate, err := s.shiftToWorkday(ruleDate, nextDateRequest.Repeat.Shift)
+		if err != nil {
+			return time.Time{}, err
 		}
-		if len(closestWeekdaysInThisWeek) > 0 {
-			closestWeekday = slices.Min(closestWeekdaysInThisWeek)
-		} else {
-			//если следующий день по плану находится на следующей неделе
-			closestWeekday = slices.Min(wVals)
+		if shiftedDate.After(nextDateRequest.Date) && !shiftedDate.Before(nowDate) {
+			return shiftedDate, nil
 		}
 
-		if closestWeekday < currentWeekday {
-			addDaysCnt = closestWeekday - (currentWeekday - 7)
-		} else {
-			addDaysCnt = closestWeekday - currentWeekday
-		}
+		ruleRequest.Date = ruleDate
+		ruleRequest.Now = ruleDate
+	}
 
-		newDate = currentDate.AddDate(0, 0, addDaysCnt)
+	return time.Time{}, errors.New("не удалось найти рабочий день, подходящий под правило повторения")
+}
 
-	case "m":
-		var mVals []int
-		needExactMonth := false
-		dVals := nextDateRequest.Repeat.Values[0]
-		if len(nextDateRequest.Repeat.Values) == 2 {
-			needExactMonth = true
-			mVals = nextDateRequest.Repeat.Values[1]
-		}
+// calculateRuleNextDate вычисляет следующую дату задания по правилу повторения без переноса на рабочий день
+func (s *Service) calculateRuleNextDate(nextDateRequest model.NextDateRequest) (time.Time, error) {
+	if nextDateRequest.Repeat.Kind == nil {
+		return time.Time{}, errors.New("не указано правило повторения")
+	}
 
-		repeatDaysMap := make(map[int]bool, len(dVals))
-		repeatMonthsMap := make(map[int]bool, len(dVals))
-		hasLastDayRepeat := false
-		hasPreLastDayRepeat := false
-		for _, dVal := range dVals {
-			if dVal > 0 {
-				repeatDaysMap[dVal] = true
-			} else if dVal == -1 {
-				hasLastDayRepeat = true
-			} else if dVal == -2 {
-				hasPreLastDayRepeat = true
-			}
+	return nextDateRequest.Repeat.Kind.Next(s, nextDateRequest)
+}
+
+// CalculateNextDates вычисляет несколько ближайших дат задания, но не больше model.MaxNextDatesCount
+func (s *Service) CalculateNextDates(request model.NextDatesRequest) ([]time.Time, error) {
+	count := request.Count
+	if count == 0 {
+		count = model.DefaultNextDatesCount
+		if !request.Until.IsZero() {
+			count = model.MaxNextDatesCount
+		}
+	}
+	count = min(count, model.MaxNextDatesCount)
+
+	dates := make([]time.Time, 0, count)
+	nextDateRequest := request.NextDateRequest
+	//Дата каждого следующего повторения может быть сдвинута коротким месяцем, поэтому день берём из исходной даты
+	nextDateRequest.Repeat = anchorRepeatRule(nextDateRequest.Repeat, nextDateRequest.Date)
+	for len(dates) < count {
+		nextDate, err := s.CalculateNextDate(nextDateRequest)
+		if err != nil {
+			return nil, err
 		}
-		for _, mVal := range mVals {
-			repeatMonthsMap[mVal] = true
+		if !request.Until.IsZero() && nextDate.After(request.Until) {
+			break
 		}
+		dates = append(dates, nextDate)
 
-		isCurrentDatePast := currentDate.Before(nowDate)
+		//Следующее повторение ищем строго после только что найденного
+		nextDateRequest.Date = nextDate
+		nextDateRequest.Now = nextDate
+	}
 
-		//Если дата где-то в прошлом, то сразу доведём до сегодняшней даты
-		if isCurrentDatePast {
-			currentDate = nowDate
-		}
+	return dates, nil
+}
 
-		newDay := currentDate.Day()
-		newMonth := int(currentDate.Month())
+// IsWorkday проверяет, что дата попадает на рабочий день недели и не является праздником
+func (s *Service) IsWorkday(date time.Time) (bool, error) {
+	if !s.workdays[weekdayNumber(date)] {
+		return false, nil
+	}
 
-		lastMonthDay, preLastMonthDay := get2LastMonthDays(currentDate)
+	if s.holidays == nil {
+		return true, nil
+	}
 
-		newDate = currentDate
-		//Если дата задачи была в прошлом и сегодняшняя дата попадает под правило повторения
-		if isCurrentDatePast && (!needExactMonth || repeatMonthsMap[newMonth]) {
-			if hasPreLastDayRepeat && newDay == preLastMonthDay {
-				break
-			}
-			if hasLastDayRepeat && newDay == lastMonthDay {
-				break
-			}
-			if repeatDaysMap[newDay] {
-				break
-			}
-		}
+	isHoliday, err := s.holidays.IsHoliday(date)
+	if err != nil {
+		return false, err
+	}
 
-		for true {
-			newDate = newDate.AddDate(0, 0, 1)
-			if int(newDate.Month()) != newMonth {
-				lastMonthDay, preLastMonthDay = get2LastMonthDays(newDate)
-				newMonth = int(newDate.Month())
-			}
+	return !isHoliday, nil
+}
 
-			if !needExactMonth || repeatMonthsMap[newMonth] {
-				newDay = newDate.Day()
-				if hasPreLastDayRepeat && newDay == preLastMonthDay {
-					break
-				}
-				if hasLastDayRepeat && newDay == lastMonthDay {
-					break
-				}
-				if repeatDaysMap[newDay] {
-					break
-				}
-			}
-		}
-	case "y":
-		newDate = currentDate.AddDate(1, 0, 0)
+// LeapDay на какую дату по умолчанию переносится 29 февраля в невисокосный год
+func (s *Service) LeapDay() string {
+	return s.leapDay
+}
 
-		for newDate.Before(nowDate) {
-			newDate = newDate.AddDate(1, 0, 0)
+// shiftToWorkday переносит дату, выпавшую на нерабочий день, на следующий или предыдущий рабочий день
+func (s *Service) shiftToWorkday(date time.Time, shift string) (time.Time, error) {
+	step := 1
+	if shift == model.ShiftPrev {
+		step = -1
+	}
+
+	for i := 0; i < model.MaxSearchDays; i++ {
+		isWorkday, err := s.IsWorkday(date)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if isWorkday {
+			return date, nil
 		}
+		date = date.AddDate(0, 0, step)
 	}
 
-	return newDate, nil
+	return time.Time{}, errors.New("не удалось найти рабочий день для переноса даты")
 }
 
 // AddTask добавляет задание
 func (s *Service) AddTask(addTaskRequest model.AddTaskRequest) (model.AddTaskResponse, error) {
-	now := time.Now()
-	nowDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	now := s.requestNow(addTaskRequest.DebugNow, addTaskRequest.Timezone)
+	nowDate := startOfDay(now)
 	taskDate := nowDate
+	taskTime := addTaskRequest.Time
 	// Если дата в запросе не указана, то сегодняшнюю берём
 	if addTaskRequest.Date != "" {
 		reqDate, err := DateParse(addTaskRequest.Date)
 		if err != nil {
 			return model.AddTaskResponse{}, fmt.Errorf("ошибка парсинга даты задачи в AddTask: %s", err.Error())
 		}
+		addTaskRequest.Repeat = anchorRepeatRule(addTaskRequest.Repeat, reqDate)
 
 		//если правило повторения не указано, продолжаем с сегодняшним числом
 		if reqDate.Before(nowDate) {
 			if addTaskRequest.RepeatRaw != "" {
+				//для правил h и min следующее повторение считается от времени задачи до текущего момента
+				if IsIntradayRule(addTaskRequest.Repeat) {
+					reqDate, err = withTime(reqDate, addTaskRequest.Time)
+					if err != nil {
+						return model.AddTaskResponse{}, fmt.Errorf("ошибка парсинга времени задачи в AddTask: %s", err.Error())
+					}
+				}
 				// при указанном правиле повторения вычислем новую дату выполнения,
 				nextDate, nextDateErr := s.CalculateNextDate(model.NextDateRequest{
-					Now:    nowDate,
-					Date:   reqDate,
-					Repeat: addTaskRequest.Repeat,
+					Now:        now,
+					Date:       reqDate,
+					Repeat:     addTaskRequest.Repeat,
+					Exceptions: addTaskRequest.Exceptions,
 				})
 				if nextDateErr != nil {
-					return model.AddTaskResponse{}, fmt.Errorf("ошибка вычисления следующей даты для просроченной задачи в AddTask: %s", err.Error())
+					return model.AddTaskResponse{}, fmt.Errorf("ошибка вычисления следующей даты для просроченной задачи в AddTask: %s", nextDateErr.Error())
 				}
 				taskDate = nextDate
+				if IsIntradayRule(addTaskRequest.Repeat) {
+					taskTime = nextDate.Format(model.TimeFormat)
+				}
 			}
 		} else {
 			taskDate = reqDate
 		}
 	}
 
+	if addTaskRequest.Date == "" {
+		addTaskRequest.Repeat = anchorRepeatRule(addTaskRequest.Repeat, taskDate)
+	}
+
+	if isRepeatEnded(addTaskRequest.RepeatUntil, taskDate) {
+		return model.AddTaskResponse{}, fmt.Errorf("дата задачи %s позже даты окончания повторений %s", taskDate.Format(model.CommonDateFormat), addTaskRequest.RepeatUntil)
+	}
+
 	addedTask, addingErr := s.storage.AddTask(database.Task{
-		Date:    taskDate.Format(model.CommonDateFormat),
-		Title:   addTaskRequest.Title,
-		Comment: addTaskRequest.Comment,
-		Repeat:  addTaskRequest.RepeatRaw,
+		Date:        taskDate.Format(model.CommonDateFormat),
+		Time:        taskTime,
+		Title:       addTaskRequest.Title,
+		Comment:     addTaskRequest.Comment,
+		Repeat:      FormatRepeatRule(addTaskRequest.Repeat),
+		RepeatUntil: addTaskRequest.RepeatUntil,
+		RepeatCount: addTaskRequest.RepeatCount,
 	})
 	if addingErr != nil {
 		return model.AddTaskResponse{}, fmt.Errorf("ошибка добавления задачи в базу данных: %s", addingErr.Error())
 	}
 
+	if len(addTaskRequest.Exceptions) > 0 {
+		err := s.storage.AddTaskExceptions(strconv.Itoa(addedTask.Id), addTaskRequest.Exceptions)
+		if err != nil {
+			return model.AddTaskResponse{}, fmt.Errorf("ошибка добавления исключений задачи в базу данных: %s", err.Error())
+		}
+	}
+
 	return model.AddTaskResponse{
 		ID: addedTask.Id,
 	}, nil
@@ -206,55 +295,279 @@ func (s *Service) GetTask(request model.GetTaskRequest) (model.Task, error) {
 	if err != nil {
 		return model.Task{}, fmt.Errorf("ошибка получения задачи из базы данных: %s", err.Error())
 	}
+	exceptions, err := s.storage.GetTaskExceptions(request.TaskId)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("ошибка получения исключений задачи из базы данных: %s", err.Error())
+	}
+	missed, err := s.storage.GetTaskMissed(request.TaskId)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("ошибка получения пропущенных повторений задачи из базы данных: %s", err.Error())
+	}
 
 	return model.Task{
-		Id:      strconv.Itoa(task.Id),
-		Date:    task.Date,
-		Title:   task.Title,
-		Comment: task.Comment,
-		Repeat:  task.Repeat,
+		Id:          strconv.Itoa(task.Id),
+		Date:        task.Date,
+		Time:        task.Time,
+		Title:       task.Title,
+		Comment:     task.Comment,
+		Repeat:      task.Repeat,
+		RepeatUntil: task.RepeatUntil,
+		RepeatCount: task.RepeatCount,
+		RepeatText:  describeStoredRepeat(task.Repeat, request.Lang),
+		Exceptions:  exceptions,
+		Missed:      formatMissed(missed),
 	}, nil
 }
 
-// DoTask получить запрошенное задание
-func (s *Service) DoTask(request model.DoTaskRequest, onlyDelete bool) (bool, error) {
+// DoTask выполнить задание: повторяющееся переносится на следующую дату, остальные удаляются
+func (s *Service) DoTask(request model.DoTaskRequest, onlyDelete bool) (model.DoTaskResponse, error) {
 	taskToBeDone, err := s.storage.GetTask(request.TaskId)
 	if err != nil {
-		return false, fmt.Errorf("не удалось получить задачу для выполнения: %s", err.Error())
+		return model.DoTaskResponse{}, fmt.Errorf("не удалось получить задачу для выполнения: %s", err.Error())
 	}
-	if onlyDelete || taskToBeDone.Repeat == "" {
-		deleteErr := s.storage.DeleteTask(request.TaskId)
-		if err != nil {
-			return false, fmt.Errorf("не удалось удалить задачу из базы данных: %s", deleteErr.Error())
-		}
-
-		return true, nil
+	if onlyDelete {
+		return model.DoTaskResponse{}, s.deleteTask(request.TaskId)
+	}
+	now := s.requestNow(request.DebugNow, request.Timezone)
+	//Последнее выполнение серии с ограниченным количеством повторений
+	if taskToBeDone.Repeat == "" || taskToBeDone.RepeatCount == 1 {
+		return model.DoTaskResponse{}, s.completeTask(taskToBeDone, nil)
 	}
 
 	prevTaskDate, err := DateParse(taskToBeDone.Date)
 	if err != nil {
-		return false, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", err.Error())
+		return model.DoTaskResponse{}, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", err.Error())
 	}
 	repeatRule, err := PrepareRepeatRuleFromRawString(taskToBeDone.Repeat)
 	if err != nil {
-		return false, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", err.Error())
+		return model.DoTaskResponse{}, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", err.Error())
 	}
-	nextDate, nextDateErr := s.CalculateNextDate(model.NextDateRequest{
-		Now:    time.Now(),
-		Date:   prevTaskDate,
-		Repeat: repeatRule,
-	})
+	exceptions, err := s.storage.GetTaskExceptions(request.TaskId)
+	if err != nil {
+		return model.DoTaskResponse{}, fmt.Errorf("не удалось получить исключения задачи: %s", err.Error())
+	}
+	isIntraday := IsIntradayRule(repeatRule)
+	if isIntraday {
+		prevTaskDate, err = withTime(prevTaskDate, taskToBeDone.Time)
+		if err != nil {
+			return model.DoTaskResponse{}, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", err.Error())
+		}
+	}
+	//Следующее повторение отсчитывается от дня (а для правил h и min - от момента) фактического выполнения, а не от даты задачи
+	if repeatRule.From == model.FromDone {
+		prevTaskDate = startOfDay(now)
+		if isIntraday {
+			prevTaskDate = now.Truncate(time.Minute)
+		}
+	}
+
+	catchUp := repeatRule.CatchUp
+	if request.CatchUp != "" {
+		catchUp = request.CatchUp
+	}
+	if catchUp == "" {
+		catchUp = model.CatchUpSkip
+	}
+
+	nextDateRequest := model.NextDateRequest{
+		Now:        now,
+		Date:       prevTaskDate,
+		Repeat:     repeatRule,
+		Exceptions: exceptions,
+	}
+	//При выполнении по одному следующее повторение берётся сразу после даты задачи, даже если оно тоже уже в прошлом
+	if catchUp == model.CatchUpStep {
+		nextDateRequest.Now = prevTaskDate
+	}
+	nextDate, nextDateErr := s.CalculateNextDate(nextDateRequest)
 	if nextDateErr != nil {
-		return false, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", nextDateErr.Error())
+		return model.DoTaskResponse{}, fmt.Errorf("не удалось вычислить дату следующего выполнения: %s", nextDateErr.Error())
+	}
+	//Серия повторений закончилась
+	if isRepeatEnded(taskToBeDone.RepeatUntil, nextDate) {
+		return model.DoTaskResponse{}, s.completeTask(taskToBeDone, nil)
 	}
-	taskToBeDone.Date = nextDate.Format(model.CommonDateFormat)
 
-	editErr := s.storage.PutTask(taskToBeDone)
-	if editErr != nil {
-		return false, fmt.Errorf("ошибка редактирования выполняемой задачи в базе данных: %s", editErr.Error())
+	var missed []database.Missed
+	if catchUp == model.CatchUpRecord {
+		missed, err = s.missedOccurrences(nextDateRequest, nextDate, isIntraday, taskToBeDone.RepeatUntil)
+		if err != nil {
+			return model.DoTaskResponse{}, fmt.Errorf("не удалось вычислить пропущенные повторения: %s", err.Error())
+		}
+		if err := s.storage.AddTaskMissed(request.TaskId, missed); err != nil {
+			return model.DoTaskResponse{}, fmt.Errorf("ошибка сохранения пропущенных повторений в базе данных: %s", err.Error())
+		}
 	}
 
-	return true, nil
+	nextTask := taskToBeDone
+	nextTask.Date = nextDate.Format(model.CommonDateFormat)
+	if isIntraday {
+		nextTask.Time = nextDate.Format(model.TimeFormat)
+	}
+	if nextTask.RepeatCount > 1 {
+		nextTask.RepeatCount--
+	}
+
+	if err := s.completeTask(taskToBeDone, &nextTask); err != nil {
+		return model.DoTaskResponse{}, err
+	}
+
+	return model.DoTaskResponse{
+		Date:    nextTask.Date,
+		Time:    nextTask.Time,
+		CatchUp: catchUp,
+		Missed:  formatMissed(missed),
+	}, nil
+}
+
+// missedOccurrences собирает повторения между датой задачи и следующей датой, но не больше model.MaxMissedCount
+func (s *Service) missedOccurrences(request model.NextDateRequest, nextDate time.Time, isIntraday bool, repeatUntil string) ([]database.Missed, error) {
+	var missed []database.Missed
+	request.Now = request.Date
+	for len(missed) < model.MaxMissedCount {
+		date, err := s.CalculateNextDate(request)
+		if err != nil {
+			return nil, err
+		}
+		if !date.Before(nextDate) || isRepeatEnded(repeatUntil, date) {
+			break
+		}
+
+		occurrence := database.Missed{Date: date.Format(model.CommonDateFormat)}
+		if isIntraday {
+			occurrence.Time = date.Format(model.TimeFormat)
+		}
+		missed = append(missed, occurrence)
+
+		request.Date = date
+		request.Now = date
+	}
+
+	return missed, nil
+}
+
+// formatMissed переводит пропущенные повторения в строки формата CommonDateFormat или DateTimeFormat
+func formatMissed(missed []database.Missed) []string {
+	formatted := make([]string, 0, len(missed))
+	for _, occurrence := range missed {
+		if occurrence.Time != "" {
+			formatted = append(formatted, occurrence.Date+" "+occurrence.Time)
+			continue
+		}
+		formatted = append(formatted, occurrence.Date)
+	}
+
+	return formatted
+}
+
+// completeTask переносит выполненную задачу на следующее повторение nextTask или в корзину, если nextTask nil,
+// и записывает выполнение в историю. Время выполнения берётся по часам планировщика, а не из запроса,
+// чтобы выполнения из разных часовых поясов можно было сравнивать
+func (s *Service) completeTask(task database.Task, nextTask *database.Task) error {
+	completion := database.Completion{
+		TaskId: task.Id,
+		Title:  task.Title,
+		Date:   task.Date,
+		Time:   task.Time,
+		DoneAt: s.Now(nil).Format(model.DateTimeFormat),
+	}
+
+	if nextTask != nil {
+		completion.NextDate = nextTask.Date
+		completion.NextTime = nextTask.Time
+	}
+
+	// Без следующего повторения задача уходит в корзину, перенос задачи и запись в историю выполняются вместе
+	if err := s.storage.CompleteTask(completion, nextTask); err != nil {
+		return fmt.Errorf("ошибка сохранения выполнения задачи в базе данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// deleteTask переносит задачу в корзину, откуда её можно восстановить до очистки. Время удаления берётся по часам
+// планировщика, а не из запроса: с ним сравнивает PurgeTrash, и корзина сортируется по нему
+func (s *Service) deleteTask(taskId string) error {
+	deleteErr := s.storage.TrashTask(taskId, s.Now(nil).Format(model.DateTimeFormat))
+	if deleteErr != nil {
+		return fmt.Errorf("не удалось удалить задачу из базы данных: %s", deleteErr.Error())
+	}
+
+	return nil
+}
+
+// GetTrash задачи в корзине, сначала удалённые последними
+func (s *Service) GetTrash(request model.TrashRequest) ([]model.Task, error) {
+	dbTasks, err := s.storage.GetTrash()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить корзину из базы данных: %s", err.Error())
+	}
+
+	tasks := make([]model.Task, 0, len(dbTasks))
+	for _, task := range dbTasks {
+		tasks = append(tasks, model.Task{
+			Id:          strconv.Itoa(task.Id),
+			Date:        task.Date,
+			Time:        task.Time,
+			Title:       task.Title,
+			Comment:     task.Comment,
+			Repeat:      task.Repeat,
+			RepeatUntil: task.RepeatUntil,
+			RepeatCount: task.RepeatCount,
+			RepeatText:  describeStoredRepeat(task.Repeat, request.Lang),
+			DeletedAt:   task.DeletedAt,
+		})
+	}
+
+	return tasks, nil
+}
+
+// RestoreTask вернуть задачу из корзины вместе с её исключениями и пропущенными повторениями
+func (s *Service) RestoreTask(request model.RestoreTaskRequest) error {
+	if err := s.storage.RestoreTask(request.TaskId); err != nil {
+		return fmt.Errorf("не удалось восстановить задачу в базе данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// PurgeTrash окончательно удаляет задачи, которые пролежали в корзине дольше срока хранения, и возвращает их количество
+func (s *Service) PurgeTrash() (int, error) {
+	deletedBefore := s.Now(nil).Add(-s.trashRetention).Format(model.DateTimeFormat)
+
+	purged, err := s.storage.PurgeTrash(deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось очистить корзину в базе данных: %s", err.Error())
+	}
+
+	return purged, nil
+}
+
+// AddTaskException добавить дату, в которую повторяющееся задание пропускается
+func (s *Service) AddTaskException(request model.TaskExceptionRequest) error {
+	if _, err := s.storage.GetTask(request.TaskId); err != nil {
+		return fmt.Errorf("не удалось получить задачу для добавления исключения: %s", err.Error())
+	}
+
+	if err := s.storage.AddTaskExceptions(request.TaskId, []string{request.Date}); err != nil {
+		return fmt.Errorf("ошибка добавления исключения задачи в базу данных: %s", err.Error())
+	}
+
+	return nil
+}
+
+// DeleteTaskException удалить дату из исключений задания
+func (s *Service) DeleteTaskException(request model.TaskExceptionRequest) error {
+	// Исключения задачи в корзине хранятся до её восстановления, но не меняются
+	if _, err := s.storage.GetTask(request.TaskId); err != nil {
+		return fmt.Errorf("не удалось получить задачу для удаления исключения: %s", err.Error())
+	}
+
+	if err := s.storage.DeleteTaskException(request.TaskId, request.Date); err != nil {
+		return fmt.Errorf("ошибка удаления исключения задачи из базы данных: %s", err.Error())
+	}
+
+	return nil
 }
 
 // PutTask отредактировать информацию задания
@@ -263,10 +576,9 @@ func (s *Service) PutTask(request model.PutTaskRequest) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("ошибка парсинга даты задачи в PutTask: %s", err.Error())
 	}
-	reqDate = reqDate.AddDate(0, 0, 1)
 
 	// Если дата в запросе не указана или меньше сегодняшней, то ошибка
-	if request.Date == "" || reqDate.Before(time.Now()) {
+	if request.Date == "" || reqDate.Before(startOfDay(s.requestNow(request.DebugNow, request.Timezone))) {
 		return false, fmt.Errorf("дата задания указана неверно для PutTask: %s", request.Date)
 	}
 
@@ -275,13 +587,43 @@ func (s *Service) PutTask(request model.PutTaskRequest) (bool, error) {
 		return false, fmt.Errorf("передан не числовой ID задания: %s", convErr.Error())
 	}
 
-	editErr := s.storage.PutTask(database.Task{
-		Id:      taskId,
-		Date:    request.Date,
-		Title:   request.Title,
-		Comment: request.Comment,
-		Repeat:  request.Repeat,
-	})
+	task := database.Task{
+		Id:          taskId,
+		Date:        request.Date,
+		Time:        request.Time,
+		Title:       request.Title,
+		Comment:     request.Comment,
+		Repeat:      FormatRepeatRule(anchorRepeatRule(request.RepeatRule, reqDate)),
+		RepeatUntil: request.RepeatUntil,
+		RepeatCount: request.RepeatCount,
+	}
+
+	// Поля, которых нет в запросе, берутся из сохранённой задачи
+	if !request.HasTime || !request.HasRepeatUntil || !request.HasRepeatCount {
+		storedTask, err := s.storage.GetTask(request.Id)
+		if err != nil {
+			return false, fmt.Errorf("ошибка получения задачи из базы данных: %s", err.Error())
+		}
+		if !request.HasTime {
+			task.Time = storedTask.Time
+		}
+		if !request.HasRepeatUntil {
+			task.RepeatUntil = storedTask.RepeatUntil
+		}
+		if !request.HasRepeatCount {
+			task.RepeatCount = storedTask.RepeatCount
+		}
+	}
+
+	if IsIntradayRule(request.RepeatRule) && task.Time == "" {
+		return false, errors.New("для повторения в течение дня нужно указать время задачи")
+	}
+
+	if isRepeatEnded(task.RepeatUntil, reqDate) {
+		return false, fmt.Errorf("дата задачи %s позже даты окончания повторений %s", request.Date, task.RepeatUntil)
+	}
+
+	editErr := s.storage.PutTask(task)
 	if editErr != nil {
 		return false, fmt.Errorf("ошибка редактирования задачи в базе данных: %s", editErr.Error())
 	}
@@ -289,6 +631,79 @@ func (s *Service) PutTask(request model.PutTaskRequest) (bool, error) {
 	return true, nil
 }
 
+// PreviewDate показать, в какую дату превращается фраза, относительные фразы считаются от сегодняшней даты запроса
+func (s *Service) PreviewDate(request model.DatePreviewRequest) (model.DatePreviewResponse, error) {
+	date, err := ParseNaturalDate(request.Phrase, s.requestNow(request.DebugNow, request.Timezone))
+	if err != nil {
+		return model.DatePreviewResponse{}, err
+	}
+
+	return model.DatePreviewResponse{Date: date.Format(model.CommonDateFormat)}, nil
+}
+
+// ParseRepeat перевести правило повторения, в том числе фразу вроде "каждый понедельник и четверг", в каноническую строку
+func (s *Service) ParseRepeat(request model.ParseRepeatRequest) model.ParseRepeatResponse {
+	return model.ParseRepeatResponse{Repeat: FormatRepeatRule(request.Repeat)}
+}
+
+// GetTaskHistory история выполнения задачи, доступна и после удаления задачи
+func (s *Service) GetTaskHistory(request model.TaskHistoryRequest) ([]model.Completion, error) {
+	completions, err := s.storage.GetTaskCompletions(request.TaskId)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории выполнения из базы данных: %s", err.Error())
+	}
+
+	return formatCompletions(completions), nil
+}
+
+// GetRecentCompletions последние model.LimitCompletions выполнений всех задач
+func (s *Service) GetRecentCompletions() ([]model.Completion, error) {
+	completions, err := s.storage.GetCompletions(model.LimitCompletions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории выполнения из базы данных: %s", err.Error())
+	}
+
+	return formatCompletions(completions), nil
+}
+
+func formatCompletions(completions []database.Completion) []model.Completion {
+	formatted := make([]model.Completion, 0, len(completions))
+	for _, completion := range completions {
+		formatted = append(formatted, model.Completion{
+			Id:       strconv.Itoa(completion.Id),
+			TaskId:   strconv.Itoa(completion.TaskId),
+			Title:    completion.Title,
+			Date:     completion.Date,
+			Time:     completion.Time,
+			DoneAt:   completion.DoneAt,
+			NextDate: completion.NextDate,
+			NextTime: completion.NextTime,
+		})
+	}
+
+	return formatted
+}
+
+// Status хранилище и драйвер, с которыми запущен сервис
+func (s *Service) Status() model.StatusResponse {
+	return s.status
+}
+
+// ConvertToRRule перевести правило повторения в формат RRULE
+func (s *Service) ConvertToRRule(request model.RRuleRequest) (model.RRuleResponse, error) {
+	rrule, err := ConvertRepeatRuleToRRule(request.Repeat)
+	if err != nil {
+		return model.RRuleResponse{}, fmt.Errorf("не удалось перевести правило повторения в RRULE: %s", err.Error())
+	}
+
+	return model.RRuleResponse{RRule: rrule}, nil
+}
+
+// DescribeRepeat описать правило повторения человеческим языком
+func (s *Service) DescribeRepeat(request model.DescribeRepeatRequest) model.DescribeRepeatResponse {
+	return model.DescribeRepeatResponse{Text: DescribeRepeatRule(request.Repeat, request.Lang)}
+}
+
 // GetClosestTasks получить ближайшие задачи
 func (s *Service) GetClosestTasks(request model.ClosestTasksRequest) ([]model.Task, error) {
 	dbTasks, err := s.storage.GetTasks(request.SearchTitle, request.SearchDate)
@@ -299,11 +714,15 @@ func (s *Service) GetClosestTasks(request model.ClosestTasksRequest) ([]model.Ta
 	tasks := make([]model.Task, 0, len(dbTasks))
 	for _, task := range dbTasks {
 		tasks = append(tasks, model.Task{
-			Id:      strconv.Itoa(task.Id),
-			Date:    task.Date,
-			Title:   task.Title,
-			Comment: task.Comment,
-			Repeat:  task.Repeat,
+			Id:          strconv.Itoa(task.Id),
+			Date:        task.Date,
+			Time:        task.Time,
+			Title:       task.Title,
+			Comment:     task.Comment,
+			Repeat:      task.Repeat,
+			RepeatUntil: task.RepeatUntil,
+			RepeatCount: task.RepeatCount,
+			RepeatText:  describeStoredRepeat(task.Repeat, request.Lang),
 		})
 	}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"go_final_project/service/model"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,65 +19,182 @@ func DateParse(dateStr string) (time.Time, error) {
 	return date, err
 }
 
+// isRepeatEnded проверяет, что дата выходит за дату окончания повторений задачи
+func isRepeatEnded(repeatUntil string, date time.Time) bool {
+	if repeatUntil == "" {
+		return false
+	}
+
+	return date.Format(model.CommonDateFormat) > repeatUntil
+}
+
+// yearlyDate возвращает дату в указанном году, 29 февраля в невисокосный год переносится согласно leap
+func yearlyDate(year int, month time.Month, day int, leap string) time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if month == time.February && day == 29 && date.Month() != month && leap == model.LeapFeb28 {
+		return date.AddDate(0, 0, -1)
+	}
+
+	return date
+}
+
+// monthDate возвращает дату в указанном месяце, день -1 и дни после конца месяца заменяются последним днём месяца
+func monthDate(year int, month time.Month, day int) time.Time {
+	firstMonthDay := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	lastMonthDay, _ := get2LastMonthDays(firstMonthDay)
+	if day == -1 || day > lastMonthDay {
+		day = lastMonthDay
+	}
+
+	return firstMonthDay.AddDate(0, 0, day-1)
+}
+
+// startOfDay возвращает начало дня в UTC, как у дат задач из DateParse, чтобы сравнивать с ним даты задач без учёта времени
+func startOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// wallClock переносит показания часов в UTC без пересчёта: 23:30 по Москве становится 23:30 UTC
+func wallClock(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), time.UTC)
+}
+
 func get2LastMonthDays(date time.Time) (int, int) {
 	nextMonth := time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, date.Location())
 
 	return nextMonth.AddDate(0, 0, -1).Day(), nextMonth.AddDate(0, 0, -2).Day()
 }
 
-func PrepareRepeatRuleFromRawString(repeatRuleRaw string) (model.RepeatRule, error) {
-	repeatRule := model.RepeatRule{}
-	if repeatRuleRaw == "" {
-		return repeatRule, nil
+func joinInts(rVals []int) string {
+	rValsStr := make([]string, 0, len(rVals))
+	for _, rVal := range rVals {
+		rValsStr = append(rValsStr, strconv.Itoa(rVal))
 	}
 
-	repeatSlice := strings.Split(repeatRuleRaw, " ")
-	rLen := len(repeatSlice)
-	if rLen == 0 {
-		return repeatRule, errors.New("формат правила повторения не соблюден")
-	}
+	return strings.Join(rValsStr, ",")
+}
 
-	if rLen > 0 {
-		repeatRule.Name = repeatSlice[0]
-	}
+func parseRepeatValuesFromString(rValsString string) ([]int, error) {
+	rValsSlice := strings.Split(rValsString, ",")
+	rVals := make([]int, 0, len(rValsSlice))
 
-	if rLen == 1 {
-		return repeatRule, nil
+	for _, rValStr := range rValsSlice {
+		rValInt, convErr := strconv.Atoi(rValStr)
+		if convErr != nil {
+			return rVals, fmt.Errorf("не удалось распарсить значение для правила повторения: %s", convErr.Error())
+		}
+		rVals = append(rVals, rValInt)
 	}
 
-	repeatValues := make([][]int, 0, len(repeatSlice)-1)
-	if rLen > 1 {
-		rValsInts, err := parseRepeatValuesFromString(repeatSlice[1])
-		if err != nil {
-			return repeatRule, fmt.Errorf("не удалось распарсить 1ю группу значений для правила повторения: %s", err.Error())
-		}
-		repeatValues = append(repeatValues, rValsInts)
+	return rVals, nil
+}
+
+// parseRepeatGroups разбирает группы чисел через запятую, групп должно быть от minGroups до maxGroups
+func parseRepeatGroups(values []string, minGroups int, maxGroups int) ([][]int, error) {
+	if len(values) < minGroups || len(values) > maxGroups {
+		return nil, fmt.Errorf("ожидается от %d до %d групп значений, передано %d", minGroups, maxGroups, len(values))
 	}
 
-	if rLen > 2 {
-		rValsInts, err := parseRepeatValuesFromString(repeatSlice[2])
+	groups := make([][]int, 0, len(values))
+	for i, value := range values {
+		rValsInts, err := parseRepeatValuesFromString(value)
 		if err != nil {
-			return repeatRule, fmt.Errorf("не удалось распарсить 2ю группу значений для правила повторения: %s", err.Error())
+			return nil, fmt.Errorf("не удалось распарсить %dю группу значений для правила повторения: %s", i+1, err.Error())
 		}
-		repeatValues = append(repeatValues, rValsInts)
+		groups = append(groups, rValsInts)
 	}
 
-	repeatRule.Values = repeatValues
+	return groups, nil
+}
+
+// sortedUnique возвращает отсортированные значения без повторов
+func sortedUnique(rVals []int) []int {
+	rVals = slices.Clone(rVals)
+	slices.Sort(rVals)
 
-	return repeatRule, nil
+	return slices.Compact(rVals)
 }
 
-func parseRepeatValuesFromString(rValsString string) ([]int, error) {
+func parseWeekdayOrdinalsFromString(rValsString string) ([]model.WeekdayOrdinal, error) {
 	rValsSlice := strings.Split(rValsString, ",")
-	rVals := make([]int, 0, len(rValsSlice))
+	ordinals := make([]model.WeekdayOrdinal, 0, len(rValsSlice))
 
 	for _, rValStr := range rValsSlice {
-		rValInt, convErr := strconv.Atoi(rValStr)
+		ordinalStr, weekdayStr, found := strings.Cut(rValStr, ":")
+		if !found {
+			return ordinals, fmt.Errorf("значение %s должно быть в формате номер:день недели", rValStr)
+		}
+
+		ordinal := model.LastWeekdayOrdinal
+		if ordinalStr != model.LastWeekdayOrdinalRaw {
+			ordinalInt, convErr := strconv.Atoi(ordinalStr)
+			if convErr != nil {
+				return ordinals, fmt.Errorf("не удалось распарсить номер дня недели: %s", convErr.Error())
+			}
+			ordinal = ordinalInt
+		}
+
+		weekday, convErr := strconv.Atoi(weekdayStr)
 		if convErr != nil {
-			return rVals, fmt.Errorf("не удалось распарсить значение для правила повторения: %s", convErr.Error())
+			return ordinals, fmt.Errorf("не удалось распарсить день недели: %s", convErr.Error())
 		}
-		rVals = append(rVals, rValInt)
+
+		ordinals = append(ordinals, model.WeekdayOrdinal{Ordinal: ordinal, Weekday: weekday})
 	}
 
-	return rVals, nil
+	return ordinals, nil
+}
+
+// weekdayNumber возвращает номер дня недели, где понедельник - 1, а воскресенье - 7
+func weekdayNumber(date time.Time) int {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		return 7
+	}
+
+	return weekday
+}
+
+// weekStart возвращает понедельник недели, в которую попадает дата
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, 1-weekdayNumber(date))
+}
+
+// daysBetween считает количество календарных дней между датами без учёта часового пояса и перехода на летнее время
+func daysBetween(from time.Time, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// isWeekdayOrdinalMatch проверяет, что дата является, например, второй средой или последней пятницей месяца
+func isWeekdayOrdinalMatch(date time.Time, ordinal model.WeekdayOrdinal) bool {
+	if weekdayNumber(date) != ordinal.Weekday {
+		return false
+	}
+
+	if ordinal.Ordinal == model.LastWeekdayOrdinal {
+		lastMonthDay, _ := get2LastMonthDays(date)
+		return date.Day()+7 > lastMonthDay
+	}
+
+	return (date.Day()-1)/7+1 == ordinal.Ordinal
+}
+
+// findNextDay ищет ближайший день после from (или начиная с него, если inclusive), подходящий под условие
+func findNextDay(from time.Time, inclusive bool, isMatch func(date time.Time) bool) (time.Time, error) {
+	date := from
+	if !inclusive {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	for i := 0; i < model.MaxSearchDays; i++ {
+		if isMatch(date) {
+			return date, nil
+		}
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, errors.New("не удалось найти дату, подходящую под правило повторения")
 }
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"fmt"
+	"go_final_project/config"
+	"go_final_project/database"
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceMemoryStorage(t *testing.T) {
+	storage := database.NewMemoryStorage()
+	svc := service.NewService(storage, storage, &config.Config{Workdays: []int{1, 2, 3, 4, 5}, Timezone: time.UTC})
+	svc.SetClock(service.FixedClock{Time: time.Date(2024, 1, 26, 10, 0, 0, 0, time.UTC)})
+
+	repeatRule, err := service.PrepareRepeatRuleFromRawString("bd 1")
+	assert.NoError(t, err)
+	added, err := svc.AddTask(model.AddTaskRequest{Date: "20240119", Title: "Отчёт", RepeatRaw: "bd 1", Repeat: repeatRule})
+	assert.NoError(t, err)
+
+	response, err := svc.DoTask(model.DoTaskRequest{TaskId: fmt.Sprint(added.ID)}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "20240129", response.Date)
+	assert.Equal(t, model.CatchUpSkip, response.CatchUp)
+	assert.Empty(t, response.Missed)
+}
+
+func TestServiceTrashPurge(t *testing.T) {
+	storage := database.NewMemoryStorage()
+	svc := service.NewService(storage, storage, &config.Config{Timezone: time.UTC, TrashRetention: 48 * time.Hour})
+	svc.SetClock(service.FixedClock{Time: time.Date(2024, 1, 26, 10, 0, 0, 0, time.UTC)})
+
+	added, err := svc.AddTask(model.AddTaskRequest{Date: "20240126", Title: "Разовая задача"})
+	assert.NoError(t, err)
+	_, err = svc.DoTask(model.DoTaskRequest{TaskId: fmt.Sprint(added.ID)}, true)
+	assert.NoError(t, err)
+
+	trash, err := svc.GetTrash(model.TrashRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "20240126 10:00", trash[0].DeletedAt)
+
+	// срок хранения ещё не истёк
+	svc.SetClock(service.FixedClock{Time: time.Date(2024, 1, 28, 9, 59, 0, 0, time.UTC)})
+	purged, err := svc.PurgeTrash()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	svc.SetClock(service.FixedClock{Time: time.Date(2024, 1, 28, 10, 1, 0, 0, time.UTC)})
+	purged, err = svc.PurgeTrash()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	trash, err = svc.GetTrash(model.TrashRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	assert.Error(t, svc.RestoreTask(model.RestoreTaskRequest{TaskId: fmt.Sprint(added.ID)}))
+}
@@ -0,0 +1,50 @@
+package service_test
+
+import (
+	"go_final_project/config"
+	"go_final_project/service"
+	"go_final_project/service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Часовые пояса на разных концах суток: сегодняшняя дата в них отличается всегда
+var timezoneNames = []string{"Pacific/Kiritimati", "Etc/GMT+12", "Europe/Moscow", "UTC"}
+
+// TestTimezoneServerLocal проверяет, что сегодняшняя дата не зависит от часового пояса сервера
+func TestTimezoneServerLocal(t *testing.T) {
+	serverLocal := time.Local
+	defer func() {
+		time.Local = serverLocal
+	}()
+
+	schedulerLocation, err := time.LoadLocation("Etc/GMT+12")
+	assert.NoError(t, err)
+	svc := service.NewService(nil, nil, &config.Config{Workdays: []int{1, 2, 3, 4, 5}, Timezone: schedulerLocation})
+	repeatRule, err := service.PrepareRepeatRuleFromRawString("d 1")
+	assert.NoError(t, err)
+
+	for _, timezoneName := range timezoneNames {
+		time.Local, err = time.LoadLocation(timezoneName)
+		assert.NoError(t, err)
+
+		today := time.Now().In(schedulerLocation).Format(model.CommonDateFormat)
+		now := svc.Now(nil)
+		assert.Equal(t, today, now.Format(model.CommonDateFormat), "часовой пояс сервера %s", timezoneName)
+
+		date, err := service.DateParse(now.AddDate(0, 0, -1).Format(model.CommonDateFormat))
+		assert.NoError(t, err)
+		next, err := svc.CalculateNextDate(model.NextDateRequest{Now: now, Date: date, Repeat: repeatRule})
+		assert.NoError(t, err)
+		assert.Equal(t, today, next.Format(model.CommonDateFormat), "часовой пояс сервера %s", timezoneName)
+
+		for _, requestTimezoneName := range timezoneNames {
+			requestLocation, err := time.LoadLocation(requestTimezoneName)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Now().In(requestLocation).Format(model.CommonDateFormat),
+				svc.Now(requestLocation).Format(model.CommonDateFormat), "часовой пояс запроса %s", requestTimezoneName)
+		}
+	}
+}
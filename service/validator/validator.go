@@ -5,70 +5,34 @@ import (
 	"fmt"
 	"go_final_project/service"
 	"go_final_project/service/model"
+	"time"
 )
 
-var ValidRepeatRuleNames = map[string]bool{
-	"d": true,
-	"y": true,
-	"w": true,
-	"m": true,
-}
-
 func ValidateRepeat(repeat model.RepeatRule) error {
-	if _, ok := ValidRepeatRuleNames[repeat.Name]; !ok {
+	if repeat.Kind == nil {
 		return errors.New("формат правила повторения не соблюден")
 	}
 
-	switch repeat.Name {
-	case "d":
-		if len(repeat.Values) != 1 || len(repeat.Values[0]) != 1 {
-			return errors.New("формат правила повторения для D не соблюден")
-		}
-
-		dVal := repeat.Values[0][0]
-		if dVal > 400 || dVal < 1 {
-			return errors.New("формат правила повторения для D не соблюден")
-		}
-	case "w":
-		if len(repeat.Values) != 1 || len(repeat.Values[0]) == 0 {
-			return errors.New("формат правила повторения для W не соблюден")
-		}
+	if repeat.Shift != "" && repeat.Shift != model.ShiftNext && repeat.Shift != model.ShiftPrev {
+		return fmt.Errorf("перенос на рабочий день может быть только %s или %s", model.ShiftNext, model.ShiftPrev)
+	}
 
-		for _, wVal := range repeat.Values[0] {
-			if wVal < 1 || wVal > 7 {
-				return errors.New("формат правила повторения для W не соблюден")
-			}
-		}
-	case "m":
-		mValsLen := len(repeat.Values)
-		if mValsLen == 0 || mValsLen > 2 ||
-			mValsLen == 1 && len(repeat.Values[0]) == 0 ||
-			mValsLen == 2 && len(repeat.Values[1]) == 0 {
-			return errors.New("формат правила повторения для M не соблюден")
-		}
+	if repeat.From != "" && repeat.From != model.FromDate && repeat.From != model.FromDone {
+		return fmt.Errorf("отсчёт следующего повторения может быть только от %s или %s", model.FromDate, model.FromDone)
+	}
 
-		if len(repeat.Values) == 1 {
-			for _, mValDay := range repeat.Values[0] {
-				if mValDay == 0 || mValDay < -2 || mValDay > 31 {
-					return errors.New("формат правила повторения для M обозначающего номер дня не соблюден")
-				}
-			}
-		}
+	if err := ValidateCatchUp(repeat.CatchUp); err != nil {
+		return err
+	}
 
-		if len(repeat.Values) == 2 {
-			for _, mValMonth := range repeat.Values[1] {
-				if mValMonth < 1 || mValMonth > 12 {
-					return errors.New("формат правила повторения для M обозначающего номер месяца не соблюден")
-				}
-			}
-		}
-	case "y":
-		if len(repeat.Values) > 0 {
-			return errors.New("формат правила повторения для Y не соблюден")
-		}
+	if repeat.Leap != "" && repeat.Leap != model.LeapFeb28 && repeat.Leap != model.LeapMar1 {
+		return fmt.Errorf("перенос 29 февраля может быть только %s или %s", model.LeapFeb28, model.LeapMar1)
+	}
+	if repeat.Leap != "" && repeat.Name != "y" {
+		return errors.New("перенос 29 февраля можно указать только для правила Y")
 	}
 
-	return nil
+	return repeat.Kind.Validate()
 }
 
 func ValidateNextDateRequest(nextDateRequest model.NextDateRequest) error {
@@ -77,9 +41,64 @@ func ValidateNextDateRequest(nextDateRequest model.NextDateRequest) error {
 		return err
 	}
 
+	return ValidateExceptions(nextDateRequest.Exceptions)
+}
+
+func ValidateExceptions(exceptions []string) error {
+	for _, exception := range exceptions {
+		_, err := service.DateParse(exception)
+		if err != nil {
+			return fmt.Errorf("дата исключения представлена в формате, отличном от %s: %s", model.CommonDateFormat, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func ValidateTaskExceptionRequest(request model.TaskExceptionRequest) error {
+	if request.TaskId == "" {
+		return errors.New("не указан идентификатор задачи")
+	}
+
+	if request.Date == "" {
+		return errors.New("не указана дата исключения")
+	}
+
+	return ValidateExceptions([]string{request.Date})
+}
+
+// ValidateRepeatEnd проверяет условия окончания повторений задачи
+func ValidateRepeatEnd(repeatRaw string, repeatUntil string, repeatCount int) error {
+	if repeatRaw == "" && (repeatUntil != "" || repeatCount != 0) {
+		return errors.New("условия окончания повторений указаны для задачи без правила повторения")
+	}
+
+	if repeatUntil != "" {
+		_, err := service.DateParse(repeatUntil)
+		if err != nil {
+			return fmt.Errorf("дата окончания повторений представлена в формате, отличном от %s: %s", model.CommonDateFormat, err.Error())
+		}
+	}
+
+	if repeatCount < 0 {
+		return errors.New("количество повторений не может быть отрицательным")
+	}
+
 	return nil
 }
 
+func ValidateNextDatesRequest(request model.NextDatesRequest) error {
+	if request.Count < 0 {
+		return errors.New("количество дат не может быть отрицательным")
+	}
+
+	if !request.Until.IsZero() && request.Until.Before(request.Date) {
+		return errors.New("дата окончания раньше даты задачи")
+	}
+
+	return ValidateNextDateRequest(request.NextDateRequest)
+}
+
 func ValidateAddTaskRequest(addTaskRequest model.AddTaskRequest) error {
 	if addTaskRequest.Title == "" {
 		return errors.New("не указан заголовок задачи")
@@ -99,7 +118,19 @@ func ValidateAddTaskRequest(addTaskRequest model.AddTaskRequest) error {
 		}
 	}
 
-	return nil
+	if err := ValidateTaskTime(addTaskRequest.Time, addTaskRequest.Repeat); err != nil {
+		return err
+	}
+
+	if addTaskRequest.RepeatRaw == "" && len(addTaskRequest.Exceptions) > 0 {
+		return errors.New("исключения указаны для задачи без правила повторения")
+	}
+
+	if err := ValidateExceptions(addTaskRequest.Exceptions); err != nil {
+		return err
+	}
+
+	return ValidateRepeatEnd(addTaskRequest.RepeatRaw, addTaskRequest.RepeatUntil, addTaskRequest.RepeatCount)
 }
 
 func ValidatePutTaskRequest(request model.PutTaskRequest) error {
@@ -121,6 +152,73 @@ func ValidatePutTaskRequest(request model.PutTaskRequest) error {
 		}
 	}
 
+	//Без time в запросе остаётся сохранённое время задачи, его для правил h и min проверяет сервис
+	if request.HasTime {
+		if err := ValidateTaskTime(request.Time, request.RepeatRule); err != nil {
+			return err
+		}
+	}
+
+	return ValidateRepeatEnd(request.Repeat, request.RepeatUntil, request.RepeatCount)
+}
+
+func ValidateTaskTime(taskTime string, repeat model.RepeatRule) error {
+	if taskTime == "" {
+		if service.IsIntradayRule(repeat) {
+			return errors.New("для повторения в течение дня нужно указать время задачи")
+		}
+		return nil
+	}
+
+	_, err := time.Parse(model.TimeFormat, taskTime)
+	if err != nil {
+		return fmt.Errorf("время представлено в формате, отличном от %s: %s", model.TimeFormat, err.Error())
+	}
+
+	return nil
+}
+
+func ValidateDatePreviewRequest(request model.DatePreviewRequest) error {
+	if request.Phrase == "" {
+		return errors.New("не указана дата")
+	}
+
+	return nil
+}
+
+func ValidateParseRepeatRequest(request model.ParseRepeatRequest) error {
+	if request.RepeatRaw == "" {
+		return errors.New("не указано правило повторения")
+	}
+
+	return ValidateRepeat(request.Repeat)
+}
+
+func ValidateRRuleRequest(request model.RRuleRequest) error {
+	if request.RepeatRaw == "" {
+		return errors.New("не указано правило повторения")
+	}
+
+	return ValidateRepeat(request.Repeat)
+}
+
+func ValidateDescribeRepeatRequest(request model.DescribeRepeatRequest) error {
+	if request.RepeatRaw == "" {
+		return errors.New("не указано правило повторения")
+	}
+
+	if err := ValidateLang(request.Lang); err != nil {
+		return err
+	}
+
+	return ValidateRepeat(request.Repeat)
+}
+
+func ValidateLang(lang string) error {
+	if !service.IsSupportedLanguage(lang) {
+		return fmt.Errorf("язык %s не поддерживается", lang)
+	}
+
 	return nil
 }
 
@@ -129,13 +227,79 @@ func ValidateGetTaskRequest(request model.GetTaskRequest) error {
 		return errors.New("не указан идентификатор задачи")
 	}
 
+	return ValidateLang(request.Lang)
+}
+
+func ValidateTaskHistoryRequest(request model.TaskHistoryRequest) error {
+	if request.TaskId == "" {
+		return errors.New("не указан идентификатор задачи")
+	}
+
 	return nil
 }
 
+func ValidateTrashRequest(request model.TrashRequest) error {
+	return ValidateLang(request.Lang)
+}
+
+func ValidateRestoreTaskRequest(request model.RestoreTaskRequest) error {
+	if request.TaskId == "" {
+		return errors.New("не указан идентификатор задачи")
+	}
+
+	return nil
+}
+
+func ValidateClosestTasksRequest(request model.ClosestTasksRequest) error {
+	return ValidateLang(request.Lang)
+}
+
 func ValidateDoTaskRequest(request model.DoTaskRequest) error {
 	if request.TaskId == "" {
 		return errors.New("не указан идентификатор задачи")
 	}
 
+	return ValidateCatchUp(request.CatchUp)
+}
+
+// ValidateCatchUp проверяет политику для пропущенных повторений просроченной задачи
+func ValidateCatchUp(catchUp string) error {
+	if catchUp != "" && catchUp != model.CatchUpSkip && catchUp != model.CatchUpStep && catchUp != model.CatchUpRecord {
+		return fmt.Errorf("пропущенные повторения можно только %s, %s или %s", model.CatchUpSkip, model.CatchUpStep, model.CatchUpRecord)
+	}
+
+	return nil
+}
+
+func ValidateHolidayRequest(request model.HolidayRequest) error {
+	if request.Date == "" {
+		return errors.New("не указана дата праздника")
+	}
+
+	_, err := service.DateParse(request.Date)
+	if err != nil {
+		return fmt.Errorf("дата праздника представлена в формате, отличном от %s: %s", model.CommonDateFormat, err.Error())
+	}
+
+	return nil
+}
+
+func ValidateDeleteHolidayRequest(request model.DeleteHolidayRequest) error {
+	if request.Date == "" {
+		return errors.New("не указана дата праздника")
+	}
+
+	return nil
+}
+
+func ValidateImportHolidaysRequest(request model.ImportHolidaysRequest) error {
+	if request.Format != model.HolidaysFormatCSV && request.Format != model.HolidaysFormatICS {
+		return fmt.Errorf("формат файла праздников может быть только %s или %s", model.HolidaysFormatCSV, model.HolidaysFormatICS)
+	}
+
+	if len(request.Content) == 0 {
+		return errors.New("передан пустой файл праздников")
+	}
+
 	return nil
 }
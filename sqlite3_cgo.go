@@ -0,0 +1,6 @@
+//go:build cgo
+
+package main
+
+// Драйвер github.com/mattn/go-sqlite3 для DSN sqlite3:// требует cgo, в сборке с CGO_ENABLED=0 его нет
+import _ "github.com/mattn/go-sqlite3"
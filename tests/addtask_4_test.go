@@ -12,9 +12,14 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func requestJSON(apipath string, values map[string]any, method string) ([]byte, error) {
+	return requestJSONWithHeaders(apipath, values, method, nil)
+}
+
+func requestJSONWithHeaders(apipath string, values map[string]any, method string, headers map[string]string) ([]byte, error) {
 	var (
 		data []byte
 		err  error
@@ -33,6 +38,9 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	client := &http.Client{}
 	if len(Token) > 0 {
@@ -60,6 +68,17 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 	return io.ReadAll(resp.Body)
 }
 
+// statusWithoutToken выполняет запрос без токена и возвращает код ответа
+func statusWithoutToken(t *testing.T, apipath string, method string) int {
+	req, err := http.NewRequest(method, getURL(apipath), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
 func postJSON(apipath string, values map[string]any, method string) (map[string]any, error) {
 	var (
 		m   map[string]any
@@ -165,7 +184,54 @@ func TestAddTask(t *testing.T) {
 	if FullNextDate {
 		tbl = []task{
 			{"20240129", "Сходить в магазин", "", "w 1,3,5"},
+			{"20240129", "Планёрка", "", "mw 2:2,last:5"},
+			{"20240129", "Бэкап", "", "cron 0 3 * * 1-5"},
+			{"20240131", "Квартальный отчёт", "", "mi 3 -1"},
 		}
 		check()
 	}
 }
+
+func TestAddTaskRRule(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	id := addTask(t, task{
+		title:  "Ревью спринта",
+		repeat: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH;INTERVAL=2",
+	})
+
+	var task Task
+	err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "w 1,4 2", task.Repeat)
+}
+
+func TestAddTaskNormalizeRepeat(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	for repeat, want := range map[string]string{
+		"w 5,1,3,1":                "w 1,3,5",
+		"w 1 1":                    "w 1",
+		"m -1,15,1,-2 8,2":         "m 1,15,-1,-2 2,8",
+		"mw last:5,2:2":            "mw 2:2,last:5",
+		"y 901,315":                "y 0315,0901",
+		"d  7  from=date":          "d 7",
+		"d 7 from=done shift=next": "d 7 shift=next from=done",
+		"каждый четверг и понедельник": "w 1,4",
+		"d 1 catchup=skip":           "d 1",
+		"d 1 catchup=step from=done": "d 1 from=done catchup=step",
+		"every last day of month":    "m -1",
+	} {
+		id := addTask(t, task{
+			title:  "Нормализация правила",
+			repeat: repeat,
+		})
+
+		var task Task
+		err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, want, task.Repeat, repeat)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_final_project/service/model"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugNowHeader(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	headers := map[string]string{model.DebugNowHeader: "20240126"}
+	taskDate := func(id string) string {
+		var task Task
+		err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		return task.Date
+	}
+
+	// 20240126 - сегодня, поэтому просроченная задача переносится по правилу повторения на 20240127
+	body, err := requestJSONWithHeaders("api/task", map[string]any{
+		"date":   "20240120",
+		"title":  "Задача на отладочную дату",
+		"repeat": "d 7 from=done",
+	}, http.MethodPost, headers)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	id := fmt.Sprint(m["id"])
+	assert.Equal(t, "20240127", taskDate(id))
+
+	body, err = requestJSONWithHeaders("api/task/done?id="+id, nil, http.MethodPost, headers)
+	assert.NoError(t, err)
+	m = nil
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, map[string]any{"date": "20240202", "catchup": "skip"}, m)
+	assert.Equal(t, "20240202", taskDate(id))
+
+	for _, v := range []struct {
+		date    string
+		wantErr bool
+	}{
+		{"20240125", true},
+		{"20240126", false},
+	} {
+		body, err = requestJSONWithHeaders("api/task", map[string]any{
+			"id":     id,
+			"date":   v.date,
+			"title":  "Задача на отладочную дату",
+			"repeat": "d 7",
+		}, http.MethodPut, headers)
+		assert.NoError(t, err)
+		m = nil
+		assert.NoError(t, json.Unmarshal(body, &m))
+		assert.Equal(t, v.wantErr, m["error"] != nil, "дата %s: %v", v.date, m)
+	}
+
+	body, err = requestJSONWithHeaders("api/nextdates?date=20240101&repeat=d+10&count=1", nil, http.MethodGet, headers)
+	assert.NoError(t, err)
+	var dates model.NextDatesResponse
+	assert.NoError(t, json.Unmarshal(body, &dates))
+	assert.Equal(t, []string{"20240131"}, dates.Dates)
+
+	// без токена заголовок не принимается даже для адресов, которые доступны без входа
+	req, err := http.NewRequest(http.MethodGet, getURL("api/nextdates?date=20240101&repeat=d+10"), nil)
+	require.NoError(t, err)
+	req.Header.Set(model.DebugNowHeader, "20240126")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_final_project/service/model"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalDateTask(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	headers := map[string]string{model.DebugNowHeader: "20240126"}
+
+	body, err := requestJSONWithHeaders("api/date/preview?date="+url.QueryEscape("через 3 дня"), nil, http.MethodGet, headers)
+	assert.NoError(t, err)
+	var preview map[string]string
+	assert.NoError(t, json.Unmarshal(body, &preview))
+	assert.Equal(t, map[string]string{"date": "20240129"}, preview)
+
+	for _, phrase := range []string{"", "abc"} {
+		body, err = requestJSONWithHeaders("api/date/preview?date="+url.QueryEscape(phrase), nil, http.MethodGet, headers)
+		assert.NoError(t, err)
+		preview = nil
+		assert.NoError(t, json.Unmarshal(body, &preview))
+		assert.NotEmpty(t, preview["error"], "фраза %q", phrase)
+	}
+
+	taskDate := func(id string) string {
+		var task Task
+		err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		return task.Date
+	}
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":  "завтра",
+		"title": "Задача на завтра",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+	assert.Equal(t, time.Now().AddDate(0, 0, 1).Format(`20060102`), taskDate(id))
+
+	body, err = requestJSONWithHeaders("api/task", map[string]any{
+		"id":    id,
+		"date":  "next monday",
+		"title": "Задача на понедельник",
+	}, http.MethodPut, headers)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Empty(t, m)
+	assert.Equal(t, "20240129", taskDate(id))
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":  "2099-10-19",
+		"title": "Задача на дату ISO 8601",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "20991019", taskDate(fmt.Sprint(ret["id"])))
+}
@@ -13,9 +13,14 @@ import (
 type Task struct {
 	ID      int64  `db:"id"`
 	Date    string `db:"date"`
+	Time    string `db:"time"`
 	Title   string `db:"title"`
 	Comment string `db:"comment"`
 	Repeat  string `db:"repeat"`
+
+	RepeatUntil string `db:"repeat_until"`
+	RepeatCount int    `db:"repeat_count"`
+	DeletedAt   string `db:"deleted_at"`
 }
 
 func count(db *sqlx.DB) (int, error) {
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importHolidays(t *testing.T, format string, content string) map[string]any {
+	req, err := http.NewRequest(http.MethodPost, getURL("api/holidays/import?format="+format), strings.NewReader(content))
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var m map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	return m
+}
+
+func getHolidays(t *testing.T, year int) []map[string]string {
+	body, err := requestJSON(fmt.Sprintf("api/holidays?year=%d", year), nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["holidays"]
+}
+
+func TestHolidays(t *testing.T) {
+	nextDate := func(date string, repeat string) string {
+		body, err := getBody(fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s", date, strings.ReplaceAll(repeat, " ", "+")))
+		assert.NoError(t, err)
+		return string(body)
+	}
+
+	ret, err := postJSON("api/holidays", map[string]any{"date": "20240129", "title": "Выходной"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, "20240130", nextDate("20240126", "bd 1"))
+	assert.Equal(t, "20240130", nextDate("20240126", "m 28 shift=next"))
+
+	ret, err = postJSON("api/holidays", map[string]any{"date": "20240129", "title": "Корпоративный выходной"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	m := importHolidays(t, "csv", "date,title\n2024-02-23,День защитника Отечества\n08.03.2024,Международный женский день\n")
+	assert.Equal(t, float64(2), m["imported"])
+	m = importHolidays(t, "ics", strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240501",
+		"DTEND;VALUE=DATE:20240503",
+		"SUMMARY:Праздник весны",
+		"  и труда",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n"))
+	assert.Equal(t, float64(2), m["imported"])
+
+	holidays := getHolidays(t, 2024)
+	assert.Equal(t, []map[string]string{
+		{"date": "20240129", "title": "Корпоративный выходной"},
+		{"date": "20240223", "title": "День защитника Отечества"},
+		{"date": "20240308", "title": "Международный женский день"},
+		{"date": "20240501", "title": "Праздник весны и труда"},
+		{"date": "20240502", "title": "Праздник весны и труда"},
+	}, holidays)
+	assert.Equal(t, "20240226", nextDate("20240222", "bd 1"))
+
+	for _, v := range holidays {
+		ret, err = postJSON("api/holidays?date="+v["date"], nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	assert.Empty(t, getHolidays(t, 2024))
+	assert.Equal(t, "20240129", nextDate("20240126", "bd 1"))
+
+	for _, values := range []map[string]any{
+		{"date": "29.01.2024", "title": "Выходной"},
+		{"title": "Выходной"},
+	} {
+		ret, err = postJSON("api/holidays", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для праздника %v", values)
+	}
+	ret, err = postJSON("api/holidays?date=20240129", nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	assert.NotEmpty(t, importHolidays(t, "xls", "20240129")["error"])
+	assert.NotEmpty(t, importHolidays(t, "csv", "20240129\nзавтра")["error"])
+	assert.NotEmpty(t, importHolidays(t, "ics", strings.Join([]string{
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240101",
+		"DTEND;VALUE=DATE:99991231",
+		"SUMMARY:Бесконечный праздник",
+		"END:VEVENT",
+	}, "\r\n"))["error"])
+	assert.Empty(t, getHolidays(t, 2024))
+}
+
+func TestHolidaysAuth(t *testing.T) {
+	if len(Token) == 0 {
+		t.Skip("без пароля авторизация не проверяется")
+	}
+
+	for _, v := range []struct {
+		path   string
+		method string
+	}{
+		{"api/holidays?year=2024", http.MethodGet},
+		{"api/holidays?date=20240101", http.MethodDelete},
+		{"api/holidays/import?format=csv", http.MethodPost},
+		{"api/task/exception?id=1&date=20240101", http.MethodDelete},
+		{"api/task?id=1", http.MethodGet},
+	} {
+		assert.Equal(t, http.StatusUnauthorized, statusWithoutToken(t, v.path, v.method), "%s %s", v.method, v.path)
+	}
+}
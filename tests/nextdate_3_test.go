@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"testing"
@@ -29,6 +31,49 @@ func TestNextDate(t *testing.T) {
 		{"20231231", "y", `20241231`},
 		{"20240229", "y", `20250301`},
 		{"20240301", "y", `20250301`},
+		{"20240229", "y leap=feb28", `20250228`},
+		{"20200229", "y", `20240229`},
+		{"20240126", "y 0315,0901", `20240315`},
+		{"20240401", "y 0315,0901", `20240901`},
+		{"20241001", "y 0315,0901", `20250315`},
+		{"20200101", "y 0315 2", `20240315`},
+		{"20210101", "y 0315 2", `20250315`},
+		{"20240301", "y 0229", `20250301`},
+		{"20240301", "y 0229 leap=feb28", `20250228`},
+		{"20240126", "y 0229 4", `20240229`},
+		{"20240229", "y 0229 4", `20280229`},
+		{"20240126", "y 1301", ""},
+		{"20240126", "h 3", `20240126`},
+		{"20240125", "h 5", `20240126`},
+		{"20240125", "min 30", `20240126`},
+		{"20240126", "h 0", ""},
+		{"20240126", "h 169", ""},
+		{"20240126", "min 1441", ""},
+		{"20240126", "h 3,4", ""},
+		{"20240113", "d 7 from=done", `20240127`},
+		{"20240113", "d 7 from=later", ""},
+		{"20240110", "mi 3", "20240410"},
+		{"20240126", "mi 1", "20240226"},
+		{"20240131", "mi 1", "20240229"},
+		{"20231130", "mi 3 31", "20240229"},
+		{"20240201", "mi 3 10", "20240210"},
+		{"20240126", "mi 3 10", "20240410"},
+		{"20240126", "mi 6 -1", "20240131"},
+		{"20230510", "mi 3", "20240210"},
+		{"20231026", "mi 3", "20240126"},
+		{"20240126", "mi 2 shift=next", "20240326"},
+		{"20240126", "mi 0", ""},
+		{"20240126", "mi 121", ""},
+		{"20240126", "mi 3 32", ""},
+		{"20240126", "mi 3 -2", ""},
+		{"20240126", "mi", ""},
+		{"20240126", "y 0230", ""},
+		{"20240126", "y 0000", ""},
+		{"20240126", "y 0315,", ""},
+		{"20240126", "y 0315 0", ""},
+		{"20240126", "y 0315 101", ""},
+		{"20240126", "y leap=feb29", ""},
+		{"20240126", "d 3 leap=feb28", ""},
 		{"20240113", "d", ""},
 		{"20240113", "d 7", `20240127`},
 		{"20240120", "d 20", `20240209`},
@@ -72,8 +117,383 @@ func TestNextDate(t *testing.T) {
 		{"20240201", "m -1,18", "20240218"},
 		{"20240125", "w 1,2,3", "20240129"},
 		{"20240126", "w 7", "20240128"},
+		{"20240129", "w 1", "20240205"},
 		{"20230126", "w 4,5", "20240201"},
 		{"20230226", "w 8,4,5", ""},
+		{"20240122", "w 1,4 2", "20240205"},
+		{"20240115", "w 1,4 2", "20240129"},
+		{"20240125", "w 4 3", "20240215"},
+		{"20240126", "w 1 1", "20240129"},
+		{"20240126", "w 1 0", ""},
+		{"20240126", "w 1 53", ""},
+		{"20240126", "w 1 2,3", ""},
+		{"20240126", "mw 2:2", "20240213"},
+		{"20240101", "mw last:5", "20240126"},
+		{"20240126", "mw last:5", "20240223"},
+		{"20240126", "mw 1:1,last:5 3,8", "20240304"},
+		{"20240126", "mw 5:4 2", "20240229"},
+		{"20240126", "mw 6:1", ""},
+		{"20240126", "mw 2:8", ""},
+		{"20240126", "mw 2", ""},
+		{"20240126", "mw last:1 13", ""},
+		{"20240113", "RRULE:FREQ=DAILY;INTERVAL=7", "20240127"},
+		{"20240125", "RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE", "20240129"},
+		{"20240122", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "20240205"},
+		{"20240127", "RRULE:FREQ=MONTHLY;BYMONTHDAY=-1", "20240131"},
+		{"20240126", "RRULE:FREQ=MONTHLY;BYDAY=2TU", "20240213"},
+		{"20240101", "rrule:freq=yearly", "20250101"},
+		{"20200101", "RRULE:FREQ=YEARLY;INTERVAL=2;BYMONTH=3,9;BYMONTHDAY=1", "20240301"},
+		{"20240126", "RRULE:FREQ=YEARLY;INTERVAL=2", ""},
+		{"20240110", "RRULE:FREQ=MONTHLY;INTERVAL=3", "20240410"},
+		{"20240126", "RRULE:FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=10", "20240410"},
+		{"20240126", "RRULE:FREQ=MONTHLY;INTERVAL=3;BYDAY=2TU", ""},
+		{"20240126", "RRULE:FREQ=YEARLY;BYMONTH=3", ""},
+		{"20240126", "RRULE:FREQ=HOURLY", ""},
+		{"20240126", "RRULE:FREQ=DAILY;COUNT=3", ""},
+		{"20240126", "RRULE:INTERVAL=2", ""},
+		{"20240126", "RRULE:FREQ=DAILY;INTERVAL=401", ""},
+		{"20240126", "bd 1", "20240129"},
+		{"20240122", "bd 5", "20240129"},
+		{"20240101", "bd 3", "20240130"},
+		{"20240126", "bd 0", ""},
+		{"20240126", "bd 401", ""},
+		{"20240126", "m 17 shift=prev", "20240216"},
+		{"20240126", "m 17 shift=next", "20240219"},
+		{"20240216", "m 17 shift=prev", "20240315"},
+		{"20240126", "m 2 shift=prev", "20240202"},
+		{"20240120", "d 7 shift=next", "20240129"},
+		{"20240126", "m 17 shift=later", ""},
+		{"20240126", "m 17 foo=bar", ""},
+		{"20240126", "cron 0 9 * * 1-5", "20240129"},
+		{"20240126", "cron 0 9 1 * *", "20240201"},
+		{"20240126", "cron 0 9 13 * 5", "20240202"},
+		{"20240126", "cron 30 8 */10 * *", "20240131"},
+		{"20240126", "cron 0 0 29 FEB *", "20240229"},
+		{"20240101", "cron 0 9 * * SUN", "20240128"},
+		{"20240126", "cron 0 9 * * 7", "20240128"},
+		{"20240126", "cron 0 9 * * 1-5 shift=next", "20240129"},
+		{"20240126", "cron 0 9 * *", ""},
+		{"20240126", "cron 60 9 * * *", ""},
+		{"20240126", "cron 0 9 32 * *", ""},
+		{"20240126", "cron 0 9 * 13 *", ""},
+		{"20240126", "cron 0 9 * * 8", ""},
+		{"20240126", "cron 0 9 5-1 * *", ""},
+		{"20240126", "cron 0 9 */0 * *", ""},
+		{"20240126", "cron 0 9 * * MON-FOO", ""},
+		{"20240126", "cron 0 0 30 2 *", ""},
 	}
 	check()
 }
+
+func TestNextDateExceptions(t *testing.T) {
+	tbl := []struct {
+		date       string
+		repeat     string
+		exceptions string
+		want       string
+	}{
+		{"20240126", "w 1", "20240129,20240205", "20240212"},
+		{"20240113", "d 7", "20240127", "20240203"},
+		{"20240126", "m 1,15", "20240201,20240215", "20240301"},
+		{"20240126", "d 1", "2024-01-27", ""},
+	}
+	for _, v := range tbl {
+		body, err := getBody(fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s&exceptions=%s",
+			v.date, url.QueryEscape(v.repeat), v.exceptions))
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(body))
+		if len(v.want) == 0 {
+			_, err = time.Parse("20060102", next)
+			assert.Error(t, err, "Ожидается ошибка для %v", v)
+			continue
+		}
+		assert.Equal(t, v.want, next, "%v", v)
+	}
+}
+
+func TestRRule(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		want   string
+	}{
+		{"d 3", "RRULE:FREQ=DAILY;INTERVAL=3"},
+		{"w 1,4 2", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{"m 1,-1 2,8", "RRULE:FREQ=MONTHLY;BYMONTHDAY=1,-1;BYMONTH=2,8"},
+		{"mw 2:2,last:5", "RRULE:FREQ=MONTHLY;BYDAY=2TU,-1FR"},
+		{"y", "RRULE:FREQ=YEARLY"},
+		{"y 0301,0901 2", "RRULE:FREQ=YEARLY;INTERVAL=2;BYMONTH=3,9;BYMONTHDAY=1"},
+		{"y 0315,0901", ""},
+		{"mi 3 10", "RRULE:FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=10"},
+		{"mi 1", "RRULE:FREQ=MONTHLY"},
+		{"mi 1 31", ""},
+		{"y 0229 leap=feb28", ""},
+		{"d 7 from=done", ""},
+		{"d 1 catchup=record", ""},
+		{"bd 5", ""},
+		{"m 15 shift=prev", ""},
+		{"", ""},
+		{"k 34", ""},
+	}
+	for _, v := range tbl {
+		body, err := getBody("api/repeat/rrule?repeat=" + url.QueryEscape(v.repeat))
+		assert.NoError(t, err)
+
+		var m map[string]string
+		err = json.Unmarshal(body, &m)
+		assert.NoError(t, err)
+		if len(v.want) == 0 {
+			assert.NotEmpty(t, m["error"], "Ожидается ошибка для правила %q", v.repeat)
+			continue
+		}
+		assert.Equal(t, v.want, m["rrule"])
+	}
+}
+
+func TestParseRepeat(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		want   string
+	}{
+		{"ежедневно", "d 1"},
+		{"каждый день", "d 1"},
+		{"каждые 3 дня", "d 3"},
+		{"раз в 10 дней", "d 10"},
+		{"еженедельно", "d 7"},
+		{"каждые 2 недели", "d 14"},
+		{"каждый понедельник и четверг", "w 1,4"},
+		{"по понедельникам, средам и пятницам", "w 1,3,5"},
+		{"каждые 2 недели по вторникам", "w 2 2"},
+		{"каждый будний день", "w 1,2,3,4,5"},
+		{"по выходным", "w 6,7"},
+		{"каждое 1 и 15 число", "m 1,15"},
+		{"15-го числа каждого месяца", "m 15"},
+		{"в последний день месяца", "m -1"},
+		{"каждый предпоследний день месяца", "m -2"},
+		{"ежемесячно", "mi 1"},
+		{"каждые 3 месяца", "mi 3"},
+		{"каждые 3 месяца 10 числа", "mi 3 10"},
+		{"каждые 2 месяца в последний день", "mi 2 -1"},
+		{"ежегодно", "y"},
+		{"каждые 2 года", "mi 24"},
+		{"каждые 3 часа", "h 3"},
+		{"каждые 30 минут", "min 30"},
+		{"Daily", "d 1"},
+		{"every 2 days", "d 2"},
+		{"every monday and thursday", "w 1,4"},
+		{"on mondays", "w 1"},
+		{"every weekday", "w 1,2,3,4,5"},
+		{"every 1st and 15th", "m 1,15"},
+		{"every last day of month", "m -1"},
+		{"every last day of the month", "m -1"},
+		{"monthly", "mi 1"},
+		{"every 6 months", "mi 6"},
+		{"every 3 months on the 10th", "mi 3 10"},
+		{"every 3 months on the 1st and 15th", ""},
+		{"yearly", "y"},
+		{"every 2 hours", "h 2"},
+		{"w 5,1", "w 1,5"},
+		{"RRULE:FREQ=DAILY;INTERVAL=3", "d 3"},
+		{"каждые 500 дней", ""},
+		{"каждый понедельник 15 числа", ""},
+		{"каждые 3", ""},
+		{"когда-нибудь", ""},
+		{"every 2 3 days", ""},
+		{"", ""},
+	}
+	for _, v := range tbl {
+		body, err := getBody("api/repeat/parse?repeat=" + url.QueryEscape(v.repeat))
+		assert.NoError(t, err)
+
+		var m map[string]string
+		err = json.Unmarshal(body, &m)
+		assert.NoError(t, err)
+		if len(v.want) == 0 {
+			assert.NotEmpty(t, m["error"], "Ожидается ошибка для правила %q", v.repeat)
+			continue
+		}
+		assert.Equal(t, v.want, m["repeat"], "правило %q", v.repeat)
+	}
+}
+
+func TestNextDates(t *testing.T) {
+	getDates := func(query string) map[string]any {
+		body, err := getBody("api/nextdates?now=20240126&" + query)
+		assert.NoError(t, err)
+
+		var m map[string]any
+		err = json.Unmarshal(body, &m)
+		assert.NoError(t, err)
+		return m
+	}
+	datesOf := func(m map[string]any) []string {
+		var dates []string
+		for _, date := range m["dates"].([]any) {
+			dates = append(dates, fmt.Sprint(date))
+		}
+		return dates
+	}
+
+	m := getDates("date=20240113&repeat=d+7&count=3")
+	assert.Equal(t, []string{"20240127", "20240203", "20240210"}, datesOf(m))
+
+	m = getDates("date=20240122&repeat=" + url.QueryEscape("w 1,4 2") + "&count=4")
+	assert.Equal(t, []string{"20240205", "20240208", "20240219", "20240222"}, datesOf(m))
+
+	m = getDates("date=20240126&repeat=" + url.QueryEscape("m -1") + "&until=20240501")
+	assert.Equal(t, []string{"20240131", "20240229", "20240331", "20240430"}, datesOf(m))
+
+	// для правил h и min даты возвращаются со временем
+	m = getDates("date=20240126&repeat=" + url.QueryEscape("h 3") + "&count=3")
+	assert.Equal(t, []string{"20240126 03:00", "20240126 06:00", "20240126 09:00"}, datesOf(m))
+	m = getDates("date=20240126&repeat=" + url.QueryEscape("min 30") + "&count=2")
+	assert.Equal(t, []string{"20240126 00:30", "20240126 01:00"}, datesOf(m))
+
+	// день исходной даты не теряется после короткого месяца
+	m = getDates("date=20240131&repeat=" + url.QueryEscape("mi 1") + "&count=6")
+	assert.Equal(t, []string{"20240229", "20240331", "20240430", "20240531", "20240630", "20240731"}, datesOf(m))
+	m = getDates("date=20231031&repeat=" + url.QueryEscape("mi 2") + "&count=4")
+	assert.Equal(t, []string{"20240229", "20240430", "20240630", "20240831"}, datesOf(m))
+
+	// 29 февраля возвращается в каждый високосный год
+	m = getDates("date=20240229&repeat=y&count=8")
+	assert.Equal(t, []string{"20250301", "20260301", "20270301", "20280229",
+		"20290301", "20300301", "20310301", "20320229"}, datesOf(m))
+
+	m = getDates("date=20240126&repeat=" + url.QueryEscape("d 1") + "&count=100000")
+	assert.Len(t, datesOf(m), 100)
+
+	m = getDates("date=20240126&repeat=y")
+	assert.Len(t, datesOf(m), 10)
+
+	for _, query := range []string{
+		"date=20240126&repeat=d+401",
+		"date=20240126&repeat=d+1&count=-1",
+		"date=20240126&repeat=d+1&count=abc",
+		"date=20240126&repeat=d+1&until=20240101",
+		"date=20240126",
+	} {
+		m = getDates(query)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для запроса %s", query)
+	}
+}
+
+func TestMonthsIntervalAnchor(t *testing.T) {
+	headers := map[string]string{"X-Debug-Now": "20240126 10:00"}
+	body, err := requestJSONWithHeaders("api/task", map[string]any{
+		"date":   "20240131",
+		"title":  "Оплатить аренду",
+		"repeat": "mi 1",
+	}, http.MethodPost, headers)
+	assert.NoError(t, err)
+	var ret map[string]any
+	assert.NoError(t, json.Unmarshal(body, &ret))
+	id := fmt.Sprint(ret["id"])
+
+	var dates []string
+	for i := 0; i < 4; i++ {
+		body, err = requestJSONWithHeaders("api/task/done?id="+id, nil, http.MethodPost, headers)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		dates = append(dates, fmt.Sprint(m["date"]))
+	}
+	assert.Equal(t, []string{"20240229", "20240331", "20240430", "20240531"}, dates)
+
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]any
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "mi 1 31", task["repeat"])
+}
+
+func TestYearsLeapAnchor(t *testing.T) {
+	headers := map[string]string{"X-Debug-Now": "20240126 10:00"}
+	body, err := requestJSONWithHeaders("api/task", map[string]any{
+		"date":   "20240229",
+		"title":  "День рождения",
+		"repeat": "y",
+	}, http.MethodPost, headers)
+	assert.NoError(t, err)
+	var ret map[string]any
+	assert.NoError(t, json.Unmarshal(body, &ret))
+	id := fmt.Sprint(ret["id"])
+
+	var dates []string
+	for i := 0; i < 4; i++ {
+		body, err = requestJSONWithHeaders("api/task/done?id="+id, nil, http.MethodPost, headers)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		dates = append(dates, fmt.Sprint(m["date"]))
+	}
+	assert.Equal(t, []string{"20250301", "20260301", "20270301", "20280229"}, dates)
+
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]any
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "y 0229", task["repeat"])
+}
+
+func TestDescribeRepeat(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		lang   string
+		want   string
+	}{
+		{"d 1", "ru", "ежедневно"},
+		{"d 3", "ru", "раз в 3 дня"},
+		{"d 21", "ru", "раз в 21 день"},
+		{"y", "ru", "ежегодно"},
+		{"w 1,4", "ru", "по понедельникам и четвергам"},
+		{"w 1,3,5 2", "ru", "раз в 2 недели по понедельникам, средам и пятницам"},
+		{"m 1,-1 2,8", "ru", "1-го и последнего числа в феврале и августе"},
+		{"m 15", "ru", "15-го числа каждого месяца"},
+		{"mw 2:2,last:5", "ru", "во второй вторник и в последнюю пятницу месяца"},
+		{"mw 1:7 3", "ru", "в первое воскресенье марта"},
+		{"d 1", "en", "every day"},
+		{"w 1,4 2", "en", "every 2 weeks on Monday and Thursday"},
+		{"m 1,-1 2,8", "en", "on the 1st and last day of February and August"},
+		{"mw 2:2,last:5", "en", "on the 2nd Tuesday and last Friday of every month"},
+		{"y", "en", "every year"},
+		{"y 0315,0901", "ru", "ежегодно 15 марта и 1 сентября"},
+		{"mi 3 10", "ru", "раз в 3 месяца 10-го числа"},
+		{"mi 1 -1", "ru", "ежемесячно в последний день месяца"},
+		{"mi 6", "en", "every 6 months"},
+		{"d 7 from=done", "ru", "раз в 7 дней, считая от дня выполнения"},
+		{"d 1 catchup=record", "ru", "ежедневно, пропущенные повторения записываются"},
+		{"d 1 catchup=step", "en", "every day, missed occurrences are done one at a time"},
+		{"h 3", "ru", "раз в 3 часа"},
+		{"min 1", "ru", "каждую минуту"},
+		{"h 1", "en", "every hour"},
+		{"min 15", "en", "every 15 minutes"},
+		{"d 7 from=done", "en", "every 7 days, counted from the completion date"},
+		{"mi 1 10", "en", "every month on the 10th day"},
+		{"y 0315 2", "ru", "раз в 2 года 15 марта"},
+		{"y 0229 leap=feb28", "ru", "ежегодно 29 февраля, 29 февраля в невисокосный год переносится на 28 февраля"},
+		{"y 0315,0901 5", "en", "every 5 years on March 15 and September 1"},
+		{"y 0229 leap=mar1", "en", "every year on February 29, February 29 moves to March 1 in common years"},
+		{"bd 5", "ru", "раз в 5 рабочих дней"},
+		{"cron 0 9 * * 1-5", "ru", "по расписанию cron «0 9 * * 1-5»"},
+		{"cron 0 9 * * 1-5", "en", `on cron schedule "0 9 * * 1-5"`},
+		{"bd 1", "en", "every working day"},
+		{"m 15 shift=prev", "ru", "15-го числа каждого месяца, с переносом на предыдущий рабочий день"},
+		{"m 15 shift=next", "en", "on the 15th day of every month, moved to the next working day"},
+		{"d 1", "de", ""},
+		{"k 34", "ru", ""},
+		{"", "ru", ""},
+	}
+	for _, v := range tbl {
+		body, err := getBody(fmt.Sprintf("api/repeat/describe?repeat=%s&lang=%s", url.QueryEscape(v.repeat), v.lang))
+		assert.NoError(t, err)
+
+		var m map[string]string
+		err = json.Unmarshal(body, &m)
+		assert.NoError(t, err)
+		if len(v.want) == 0 {
+			assert.NotEmpty(t, m["error"], "Ожидается ошибка для правила %q", v.repeat)
+			continue
+		}
+		assert.Equal(t, v.want, m["text"])
+	}
+}
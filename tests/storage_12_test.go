@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"encoding/json"
+	"go_final_project/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	body, err := getBody("api/status")
+	assert.NoError(t, err)
+
+	var status map[string]string
+	assert.NoError(t, json.Unmarshal(body, &status))
+	assert.Equal(t, config.StorageSQLite, status["storage"])
+	assert.Equal(t, config.StorageDrivers[config.StorageSQLite], status["driver"])
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"testing"
 	"time"
@@ -46,6 +47,34 @@ func TestTask(t *testing.T) {
 	assert.Equal(t, task.title, m["title"])
 	assert.Equal(t, task.comment, m["comment"])
 	assert.Equal(t, task.repeat, m["repeat"])
+	assert.Equal(t, "раз в 5 дней", m["repeat_text"])
+}
+
+func TestTaskInvalidStoredRepeat(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	// правило, сохранённое в базе данных в обход проверки, не описывается, но задача возвращается
+	for _, repeat := range []string{"w 8", "m 1 13", "mw 6:1"} {
+		res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, '', ?)`,
+			"20240126", "Старое правило", repeat)
+		assert.NoError(t, err)
+		id, err := res.LastInsertId()
+		assert.NoError(t, err)
+
+		body, err := requestJSON(fmt.Sprintf("api/task?id=%d", id), nil, http.MethodGet)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m), "ответ для правила %q: %s", repeat, body)
+		assert.Equal(t, repeat, m["repeat"])
+		assert.NotContains(t, m, "repeat_text")
+	}
+
+	body, err := requestJSON("api/tasks?search="+url.QueryEscape("Старое правило"), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m), "ответ: %s", body)
+	assert.Len(t, m["tasks"], 3)
 }
 
 type fulltask struct {
@@ -130,3 +159,118 @@ func TestEditTask(t *testing.T) {
 		"repeat":  "d 7",
 	})
 }
+
+func TestEditTaskKeepsOmittedFields(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	ret, err := postJSON("api/task", map[string]any{
+		"date":         date,
+		"time":         "09:30",
+		"title":        "Полить цветы",
+		"repeat":       "d 2",
+		"repeat_until": "20991231",
+		"repeat_count": 5,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	// Веб-интерфейс не присылает time, repeat_until и repeat_count
+	ret, err = postJSON("api/task", map[string]any{
+		"id":      id,
+		"date":    date,
+		"title":   "Полить цветы на балконе",
+		"comment": "",
+		"repeat":  "d 3",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Полить цветы на балконе", task.Title)
+	assert.Equal(t, "d 3", task.Repeat)
+	assert.Equal(t, "09:30", task.Time)
+	assert.Equal(t, "20991231", task.RepeatUntil)
+	assert.Equal(t, 5, task.RepeatCount)
+
+	// Переданные поля заменяют прежние значения, в том числе пустыми
+	ret, err = postJSON("api/task", map[string]any{
+		"id":           id,
+		"date":         date,
+		"time":         "",
+		"title":        "Полить цветы на балконе",
+		"repeat":       "d 3",
+		"repeat_until": "",
+		"repeat_count": 0,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "", task.Time)
+	assert.Equal(t, "", task.RepeatUntil)
+	assert.Equal(t, 0, task.RepeatCount)
+	// Задача с повторением в течение дня сохраняет время, если его нет в запросе
+	ret, err = postJSON("api/task", map[string]any{
+		"date":   date,
+		"time":   "08:00",
+		"title":  "Размяться",
+		"repeat": "h 2",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	intradayID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id":     intradayID,
+		"date":   date,
+		"title":  "Размяться и попить воды",
+		"repeat": "h 2",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, intradayID))
+	assert.Equal(t, "Размяться и попить воды", task.Title)
+	assert.Equal(t, "08:00", task.Time)
+
+	// без сохранённого времени правило h по-прежнему требует time
+	ret, err = postJSON("api/task", map[string]any{
+		"id":     id,
+		"date":   date,
+		"title":  "Полить цветы на балконе",
+		"repeat": "h 2",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	// дата задачи не может быть позже даты окончания повторений, в том числе сохранённой
+	ret, err = postJSON("api/task", map[string]any{
+		"id":           id,
+		"date":         date,
+		"title":        "Полить цветы на балконе",
+		"repeat":       "d 3",
+		"repeat_until": time.Now().Format(`20060102`),
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id":           id,
+		"date":         date,
+		"title":        "Полить цветы на балконе",
+		"repeat":       "d 3",
+		"repeat_until": date,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	ret, err = postJSON("api/task", map[string]any{
+		"id":     id,
+		"date":   time.Now().AddDate(0, 0, 2).Format(`20060102`),
+		"title":  "Полить цветы на балконе",
+		"repeat": "d 3",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, date, task.Date)
+}
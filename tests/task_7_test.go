@@ -2,6 +2,8 @@ package tests
 
 import (
 	"encoding/json"
+	"fmt"
+	"go_final_project/service/model"
 	"net/http"
 	"testing"
 	"time"
@@ -42,16 +44,513 @@ func TestDone(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
 		assert.NoError(t, err)
-		assert.Empty(t, ret)
+		now = now.AddDate(0, 0, 3)
+		assert.Equal(t, map[string]any{"date": now.Format(`20060102`), "catchup": "skip"}, ret)
 
 		var task Task
 		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
 		assert.NoError(t, err)
-		now = now.AddDate(0, 0, 3)
 		assert.Equal(t, task.Date, now.Format(`20060102`))
 	}
 }
 
+func TestDoneWeeksInterval(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	weekday := int(now.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+
+	// задача раз в две недели, которую не выполнили неделю назад
+	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, '', ?)`,
+		now.AddDate(0, 0, -7).Format(`20060102`), "Ревью спринта", fmt.Sprintf("w %d 2", weekday))
+	assert.NoError(t, err)
+	taskID, err := res.LastInsertId()
+	assert.NoError(t, err)
+	id := fmt.Sprint(taskID)
+
+	for i := 0; i < 2; i++ {
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"date": now.AddDate(0, 0, 7+14*i).Format(`20060102`), "catchup": "skip"}, ret)
+
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 7+14*i).Format(`20060102`), task.Date)
+	}
+}
+
+func TestDoneRepeatEnd(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	// want - следующая дата задачи, пустая - серия закончилась и задача удалена
+	done := func(id string, want string) {
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		if want == "" {
+			assert.Empty(t, ret)
+			return
+		}
+		assert.Equal(t, map[string]any{"date": want, "catchup": "skip"}, ret)
+	}
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":         now.Format(`20060102`),
+		"title":        "Сеанс физиотерапии",
+		"repeat":       "d 1",
+		"repeat_count": 3,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	for i := 1; i < 3; i++ {
+		done(id, now.AddDate(0, 0, i).Format(`20060102`))
+
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, i).Format(`20060102`), task.Date)
+		assert.Equal(t, 3-i, task.RepeatCount)
+	}
+	done(id, "")
+	notFoundTask(t, id)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":         now.Format(`20060102`),
+		"title":        "Полить цветы",
+		"repeat":       "d 2",
+		"repeat_until": now.AddDate(0, 0, 3).Format(`20060102`),
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+
+	done(id, now.AddDate(0, 0, 2).Format(`20060102`))
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), task.Date)
+
+	done(id, "")
+	notFoundTask(t, id)
+
+	for _, values := range []map[string]any{
+		{"title": "Без повторения", "repeat_count": 3},
+		{"title": "Отрицательное количество", "repeat": "d 1", "repeat_count": -1},
+		{"title": "Неверная дата", "repeat": "d 1", "repeat_until": "31.12.2024"},
+	} {
+		ret, err = postJSON("api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для задачи %v", values)
+	}
+}
+
+func TestDoneExceptions(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	day := func(days int) string {
+		return now.AddDate(0, 0, days).Format(`20060102`)
+	}
+	done := func(id string, want string) {
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"date": want, "catchup": "skip"}, ret)
+
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, want, task.Date)
+	}
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":       day(-1),
+		"title":      "Зарядка",
+		"repeat":     "d 1",
+		"exceptions": []string{day(0), day(1)},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, day(2), task.Date)
+
+	done(id, day(3))
+
+	ret, err = postJSON("api/task/exception", map[string]any{"id": id, "date": day(4)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	done(id, day(5))
+
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, []any{day(0), day(1), day(4)}, m["exceptions"])
+
+	ret, err = postJSON("api/task/exception", map[string]any{"id": id, "date": day(6)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON(fmt.Sprintf("api/task/exception?id=%s&date=%s", id, day(6)), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	done(id, day(6))
+
+	for _, values := range []map[string]any{
+		{"id": id, "date": "завтра"},
+		{"id": id},
+		{"id": "7645346343", "date": day(7)},
+	} {
+		ret, err = postJSON("api/task/exception", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для исключения %v", values)
+	}
+	ret, err = postJSON(fmt.Sprintf("api/task/exception?id=%s&date=%s", id, day(6)), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"title":      "Без повторения",
+		"exceptions": []string{day(1)},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
+
+func TestDoneFromCompletion(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	day := func(days int) string {
+		return now.AddDate(0, 0, days).Format(`20060102`)
+	}
+
+	tbl := []struct {
+		date   string
+		repeat string
+		want   string
+	}{
+		// просроченная задача, следующее повторение от даты задачи
+		{day(-5), "d 7", day(2)},
+		{day(-5), "d 7 from=date", day(2)},
+		// просроченная и выполненная заранее задачи, следующее повторение от дня выполнения
+		{day(-5), "d 7 from=done", day(7)},
+		{day(3), "d 7 from=done", day(7)},
+	}
+	for _, v := range tbl {
+		res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, '', ?)`,
+			v.date, "Полить цветы", v.repeat)
+		assert.NoError(t, err)
+		taskID, err := res.LastInsertId()
+		assert.NoError(t, err)
+		id := fmt.Sprint(taskID)
+
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"date": v.want, "catchup": "skip"}, ret, "%v", v)
+
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, v.want, task.Date, "%v", v)
+		assert.Equal(t, v.repeat, task.Repeat)
+	}
+}
+
+func TestDoneIntraday(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	// раз в сутки в полночь: сегодняшняя полночь уже прошла, поэтому следующее повторение завтра
+	res, err := db.Exec(`INSERT INTO scheduler (date, time, title, comment, repeat) VALUES (?, ?, ?, '', ?)`,
+		now.AddDate(0, 0, -1).Format(`20060102`), "00:00", "Выгрузить отчёт", "h 24")
+	assert.NoError(t, err)
+	taskID, err := res.LastInsertId()
+	assert.NoError(t, err)
+	id := fmt.Sprint(taskID)
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"date": now.AddDate(0, 0, 1).Format(`20060102`), "time": "00:00", "catchup": "skip"}, ret)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	assert.Equal(t, "00:00", task.Time)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":   now.AddDate(0, 0, 1).Format(`20060102`),
+		"time":   "09:30",
+		"title":  "Проверить почту",
+		"repeat": "min 90",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"date": now.AddDate(0, 0, 1).Format(`20060102`), "time": "11:00", "catchup": "skip"}, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	assert.Equal(t, "11:00", task.Time)
+
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]string
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, "11:00", m["time"])
+
+	for _, values := range []map[string]any{
+		{"title": "Без времени", "repeat": "h 3"},
+		{"title": "Неверное время", "time": "25:00"},
+		{"title": "Неверное время", "time": "9:30am"},
+	} {
+		ret, err = postJSON("api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для задачи %v", values)
+	}
+}
+
+func TestDoneCatchUp(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	headers := map[string]string{"X-Debug-Now": "20240126 10:00"}
+	insert := func(date, taskTime, repeat string) string {
+		res, err := db.Exec(`INSERT INTO scheduler (date, time, title, comment, repeat) VALUES (?, ?, ?, '', ?)`,
+			date, taskTime, "Утренняя зарядка", repeat)
+		assert.NoError(t, err)
+		taskID, err := res.LastInsertId()
+		assert.NoError(t, err)
+		return fmt.Sprint(taskID)
+	}
+	done := func(id string, catchUp string) map[string]any {
+		body, err := requestJSONWithHeaders("api/task/done?id="+id+"&catchup="+catchUp, nil, http.MethodPost, headers)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		return m
+	}
+
+	// по умолчанию пропущенные повторения пропускаются
+	id := insert("20240123", "", "d 1")
+	assert.Equal(t, map[string]any{"date": "20240126", "catchup": "skip"}, done(id, ""))
+
+	// по одному: следующая дата может остаться в прошлом
+	id = insert("20240123", "", "d 1")
+	assert.Equal(t, map[string]any{"date": "20240124", "catchup": "step"}, done(id, "step"))
+	assert.Equal(t, map[string]any{"date": "20240125", "catchup": "step"}, done(id, "step"))
+
+	// политика из правила повторения задачи, пропущенные повторения записываются
+	id = insert("20240122", "", "d 1 catchup=record")
+	assert.Equal(t, map[string]any{
+		"date":    "20240126",
+		"catchup": "record",
+		"missed":  []any{"20240123", "20240124", "20240125"},
+	}, done(id, ""))
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, []any{"20240123", "20240124", "20240125"}, m["missed"])
+
+	// политика из запроса важнее политики задачи
+	id = insert("20240122", "", "d 1 catchup=record")
+	assert.Equal(t, map[string]any{"date": "20240126", "catchup": "skip"}, done(id, "skip"))
+
+	id = insert("20240125", "08:00", "h 8")
+	assert.Equal(t, map[string]any{
+		"date":    "20240126",
+		"time":    "16:00",
+		"catchup": "record",
+		"missed":  []any{"20240125 16:00", "20240126 00:00", "20240126 08:00"},
+	}, done(id, "record"))
+
+	assert.NotEmpty(t, done(id, "oops")["error"])
+}
+
+func TestTaskHistory(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	insert := func(date, repeat string) string {
+		res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, '', ?)`,
+			date, "Полить цветы", repeat)
+		assert.NoError(t, err)
+		taskID, err := res.LastInsertId()
+		assert.NoError(t, err)
+		return fmt.Sprint(taskID)
+	}
+	history := func(path string) []any {
+		body, err := requestJSON(path, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		list, ok := m["history"].([]any)
+		assert.True(t, ok, "ожидается список выполнений: %s", body)
+		return list
+	}
+
+	headers := map[string]string{"X-Debug-Now": "20240126 10:00"}
+	before := time.Now().Format(model.DateTimeFormat)
+	repeatID := insert("20240126", "d 3")
+	for i := 0; i < 2; i++ {
+		_, err := requestJSONWithHeaders("api/task/done?id="+repeatID, nil, http.MethodPost, headers)
+		assert.NoError(t, err)
+	}
+	onceID := insert("20240127", "")
+	_, err := requestJSONWithHeaders("api/task/done?id="+onceID, nil, http.MethodPost, headers)
+	assert.NoError(t, err)
+
+	// сначала последние выполнения
+	repeatHistory := history("api/task/history?id=" + repeatID)
+	assert.Len(t, repeatHistory, 2)
+	// время выполнения берётся по часам планировщика, а не из X-Debug-Now
+	after := time.Now().Format(model.DateTimeFormat)
+	for _, completion := range repeatHistory {
+		assert.NotEmpty(t, completion.(map[string]any)["id"])
+		delete(completion.(map[string]any), "id")
+		doneAt := fmt.Sprint(completion.(map[string]any)["done_at"])
+		assert.True(t, before <= doneAt && doneAt <= after, "время выполнения %s вне промежутка %s - %s", doneAt, before, after)
+		delete(completion.(map[string]any), "done_at")
+	}
+	assert.Equal(t, []any{
+		map[string]any{"task_id": repeatID, "title": "Полить цветы", "date": "20240129", "next_date": "20240201"},
+		map[string]any{"task_id": repeatID, "title": "Полить цветы", "date": "20240126", "next_date": "20240129"},
+	}, repeatHistory)
+
+	// разовая задача удалена, но её выполнение осталось в истории
+	notFoundTask(t, onceID)
+	onceHistory := history("api/task/history?id=" + onceID)
+	assert.Len(t, onceHistory, 1)
+	assert.Equal(t, "20240127", onceHistory[0].(map[string]any)["date"])
+	assert.NotContains(t, onceHistory[0], "next_date")
+
+	recent := history("api/history")
+	assert.GreaterOrEqual(t, len(recent), 3)
+	assert.Equal(t, onceHistory[0], recent[0])
+	assert.Equal(t, repeatID, recent[1].(map[string]any)["task_id"])
+
+	// удаление задачи не записывается как выполнение
+	deleteID := insert("20240128", "d 1")
+	_, err = requestJSON("api/task?id="+deleteID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, history("api/task/history?id="+deleteID))
+
+	body, err := requestJSON("api/task/history", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "error")
+
+	if len(Token) > 0 {
+		assert.Equal(t, http.StatusUnauthorized, statusWithoutToken(t, "api/task/history?id="+repeatID, http.MethodGet))
+		assert.Equal(t, http.StatusUnauthorized, statusWithoutToken(t, "api/history", http.MethodGet))
+	}
+}
+
+func TestTrash(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	trash := func() []map[string]any {
+		body, err := requestJSON("api/trash", nil, http.MethodGet)
+		assert.NoError(t, err)
+		var m map[string][]map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		return m["tasks"]
+	}
+	inTrash := func(id string) map[string]any {
+		for _, task := range trash() {
+			if task["id"] == id {
+				return task
+			}
+		}
+		return nil
+	}
+
+	headers := map[string]string{"X-Debug-Now": "20240126 10:00"}
+	before := time.Now().Format(model.DateTimeFormat)
+	id := addTask(t, task{
+		date:   "20240126",
+		title:  "Забрать посылку",
+		repeat: "d 3",
+	})
+	ret, err := postJSON("api/task/exception", map[string]any{"id": id, "date": "20240129"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	body, err := requestJSONWithHeaders("api/task?id="+id, nil, http.MethodDelete, headers)
+	assert.NoError(t, err)
+	assert.JSONEq(t, "{}", string(body))
+
+	// задача в корзине не видна, но хранится в базе данных
+	notFoundTask(t, id)
+	var dbTask Task
+	assert.NoError(t, db.Get(&dbTask, `SELECT * FROM scheduler WHERE id=?`, id))
+	// время удаления берётся по часам планировщика, а не из X-Debug-Now
+	after := time.Now().Format(model.DateTimeFormat)
+	assert.True(t, before <= dbTask.DeletedAt && dbTask.DeletedAt <= after,
+		"время удаления %s вне промежутка %s - %s", dbTask.DeletedAt, before, after)
+	trashed := inTrash(id)
+	assert.NotNil(t, trashed)
+	assert.Equal(t, "Забрать посылку", trashed["title"])
+	assert.Equal(t, dbTask.DeletedAt, trashed["deleted_at"])
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	// исключения задачи в корзине не меняются
+	ret, err = postJSON("api/task/exception", map[string]any{"id": id, "date": "20240201"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret["error"], "не найдена")
+	ret, err = postJSON("api/task/exception?id="+id+"&date=20240129", nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Contains(t, ret["error"], "не найдена")
+
+	// без входа корзина недоступна
+	if len(Token) > 0 {
+		assert.Equal(t, http.StatusUnauthorized, statusWithoutToken(t, "api/task/restore?id="+id, http.MethodPost))
+		assert.Equal(t, http.StatusUnauthorized, statusWithoutToken(t, "api/trash", http.MethodGet))
+		assert.NotNil(t, inTrash(id))
+	}
+
+	// восстановленная задача возвращается вместе с исключениями
+	ret, err = postJSON("api/task/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Nil(t, inTrash(id))
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, []any{"20240129"}, m["exceptions"])
+	assert.NotContains(t, m, "deleted_at")
+
+	ret, err = postJSON("api/task/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	ret, err = postJSON("api/task/restore", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	// выполненная разовая задача тоже попадает в корзину
+	onceID := addTask(t, task{date: "20240127", title: "Разовая задача"})
+	_, err = requestJSONWithHeaders("api/task/done?id="+onceID, nil, http.MethodPost, headers)
+	assert.NoError(t, err)
+	notFoundTask(t, onceID)
+	assert.NotNil(t, inTrash(onceID))
+}
+
 func TestDelTask(t *testing.T) {
 	db := openDB(t)
 	defer db.Close()
@@ -66,6 +565,10 @@ func TestDelTask(t *testing.T) {
 
 	notFoundTask(t, id)
 
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret)
+
 	ret, err = postJSON("api/task", nil, http.MethodDelete)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, ret)
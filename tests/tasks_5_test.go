@@ -106,3 +106,26 @@ func TestTasks(t *testing.T) {
 	assert.Equal(t, len(tasks), 3)
 
 }
+
+func TestTasksOrderByTime(t *testing.T) {
+	if !Search {
+		return
+	}
+
+	for _, taskTime := range []string{"18:00", "", "09:30"} {
+		ret, err := postJSON("api/task", map[string]any{
+			"date":  "20990101",
+			"time":  taskTime,
+			"title": "Задача на " + taskTime,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["id"], "%v", ret)
+	}
+
+	tasks := getTasks(t, "01.01.2099")
+	var times []string
+	for _, task := range tasks {
+		times = append(times, task["time"])
+	}
+	assert.Equal(t, []string{"", "09:30", "18:00"}, times)
+}
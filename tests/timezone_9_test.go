@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_final_project/service/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Часовые пояса на разных концах суток: сегодняшняя дата в них отличается всегда
+var timezoneNames = []string{"Pacific/Kiritimati", "Etc/GMT+12", "Europe/Moscow", "UTC"}
+
+func TestTimezoneHeader(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	for _, timezoneName := range timezoneNames {
+		location, err := time.LoadLocation(timezoneName)
+		assert.NoError(t, err)
+		headers := map[string]string{model.TimezoneHeader: timezoneName}
+
+		body, err := requestJSONWithHeaders("api/task", map[string]any{
+			"title": "Задача в часовом поясе " + timezoneName,
+		}, http.MethodPost, headers)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		id := fmt.Sprint(m["id"])
+
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Now().In(location).Format(`20060102`), task.Date, "часовой пояс %s", timezoneName)
+
+		body, err = requestJSONWithHeaders("api/nextdates?repeat=d+1&count=1&date="+
+			time.Now().In(location).AddDate(0, 0, -1).Format(`20060102`), nil, http.MethodGet, headers)
+		assert.NoError(t, err)
+		var dates model.NextDatesResponse
+		assert.NoError(t, json.Unmarshal(body, &dates))
+		assert.Equal(t, []string{time.Now().In(location).Format(`20060102`)}, dates.Dates, "часовой пояс %s", timezoneName)
+	}
+
+	body, err := requestJSONWithHeaders("api/task", map[string]any{
+		"title": "Задача в неизвестном часовом поясе",
+	}, http.MethodPost, map[string]string{model.TimezoneHeader: "Mars/Olympus"})
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.NotEmpty(t, m["error"])
+}